@@ -0,0 +1,312 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// Behavior is implemented by any value that can decide the action of
+// a monster. Act reports whether the monster acted.
+type Behavior interface {
+	Act(m *Monster) bool
+}
+
+// Mode is the state of a monster's mind. Only think moves the monster
+// between the modes; the behaviors act according to the mode.
+type Mode int
+
+const (
+	// Idle monsters wander, guard or patrol.
+	Idle Mode = iota
+	// Hunting monsters chase the player they remember.
+	Hunting
+	// Fleeing monsters run away from the player while they see them.
+	Fleeing
+	// Returning monsters go back home before idling again.
+	Returning
+)
+
+// Mind is the per-monster state of the behaviors.
+type Mind struct {
+	Mode Mode
+	// Home is the point guarded by the monster.
+	Home XY
+	// Waypoints is the patrol route and Waypoint is the index of the
+	// next waypoint.
+	Waypoints []XY
+	Waypoint  int
+	// Target is the player if seen this turn.
+	Target Fighter `json:"-"`
+	// LastSeen is the last known position of the player.
+	LastSeen   XY
+	Remembered bool
+}
+
+// perceive looks for the player in the Field of View of the monster
+// and remembers where it was seen. A stealthy player is noticed only
+// closer, but always when adjacent.
+func (m *Monster) perceive() {
+	opaque := func(p XY) bool { return m.State.Tiles[p].Opaque() }
+	v := m.Kind.Vision
+	if v.Radius = m.attributes()[SightStat]; v.Radius < 2 {
+		v.Radius = 2
+	}
+	sight := m.Kind.Sight
+	if sight == nil {
+		sight = Shadowcasting{}
+	}
+	fov := sight.FOV(m.XY, v, opaque)
+	m.Mind.Target = nil
+	for _, e := range m.State.VisibleEntities(fov) {
+		p, ok := e.(*Player)
+		if !ok || p.HP <= 0 {
+			continue
+		}
+		if d := distance(m.XY, p.XY); d > 1 && d > v.Radius-p.Effective[StealthStat] {
+			continue
+		}
+		m.Mind.Target = p
+		m.Mind.LastSeen = p.XY
+		m.Mind.Remembered = true
+	}
+}
+
+// think moves the mind of the monster to its next mode after
+// perceiving the surroundings. A monster fleeing from the player it
+// sees keeps fleeing; a wounded one starts to.
+func (m *Monster) think() {
+	afraid, guards := false, false
+	for _, b := range m.Kind.Behaviors {
+		switch b := b.(type) {
+		case Flee:
+			afraid = afraid || float64(m.HP) < b.Below*float64(m.MaxHP)
+		case Guard:
+			guards = true
+		}
+	}
+	mind := &m.Mind
+	switch {
+	case mind.Mode == Fleeing:
+		if mind.Target == nil {
+			// Safe; forget the player.
+			mind.Remembered = false
+			mind.Mode = Returning
+		}
+	case mind.Target != nil && afraid:
+		mind.Mode = Fleeing
+	case mind.Remembered:
+		mind.Mode = Hunting
+	case mind.Mode == Hunting:
+		mind.Mode = Returning
+	case mind.Mode == Returning && m.XY == mind.Home:
+		mind.Mode = Idle
+	case mind.Mode == Idle && guards && m.XY != mind.Home:
+		mind.Mode = Returning
+	}
+}
+
+// goHome steps a returning monster towards its home. Reports whether
+// the monster acted.
+func (m *Monster) goHome() bool {
+	return m.Mind.Mode == Returning && m.step(m.Mind.Home)
+}
+
+// step moves the monster one step along the path to q attacking the
+// target if it is in the way. Other blocking entities are walked
+// around. Reports whether the monster acted.
+func (m *Monster) step(q XY) bool {
+	opt := PathOptions{Diagonal: true, OpenDoors: m.Kind.OpensDoors}
+	path, ok := m.State.Path(m.XY, q, opt)
+	if !ok || len(path) == 0 {
+		return false
+	}
+	if b, ok := m.State.BlockerAt(path[0]); ok && b != m.Mind.Target {
+		opt.AvoidEntities = true
+		if path, ok = m.State.Path(m.XY, q, opt); !ok || len(path) == 0 {
+			return false
+		}
+	}
+	return m.stepTo(path[0])
+}
+
+// stepTo moves the monster to the adjacent point q attacking the
+// target if it is there. Reports whether the monster acted.
+func (m *Monster) stepTo(q XY) bool {
+	if f, ok := m.State.FighterAt(q, m); ok {
+		if f != m.Mind.Target {
+			return false
+		}
+		m.State.Attack(m, f)
+		return true
+	}
+	if t := m.State.Tiles[q]; t.Closed() && m.Kind.OpensDoors {
+		return m.State.OpenDoor(m, q)
+	}
+	if !m.State.Tiles[q].Passable() {
+		return false
+	}
+	return m.State.Move(m, q)
+}
+
+// distance returns the Chebyshev distance between p and q.
+func distance(p, q XY) int {
+	dx, dy := abs(p.X-q.X), abs(p.Y-q.Y)
+	if dx > dy {
+		return dx
+	}
+	return dy
+}
+
+// behaviors are the constructors of the behaviors by their names in
+// the data files.
+var behaviors = map[string]func() Behavior{
+	"wander":        func() Behavior { return Wander{} },
+	"chase":         func() Behavior { return Chase{} },
+	"keep distance": func() Behavior { return KeepDistance{} },
+	"flee":          func() Behavior { return Flee{} },
+	"guard":         func() Behavior { return Guard{} },
+	"patrol":        func() Behavior { return Patrol{} },
+}
+
+// behavior is a Behavior encoded in JSON as its name or as an object
+// with the name as the only key and the parameters as the value.
+type behavior struct {
+	Behavior
+}
+
+func (b *behavior) UnmarshalJSON(data []byte) error {
+	var name string
+	var params json.RawMessage
+	if err := json.Unmarshal(data, &name); err != nil {
+		var obj map[string]json.RawMessage
+		if err := json.Unmarshal(data, &obj); err != nil || len(obj) != 1 {
+			return fmt.Errorf("invalid behavior %s", data)
+		}
+		for name, params = range obj {
+		}
+	}
+	newBehavior, ok := behaviors[name]
+	if !ok {
+		return fmt.Errorf("unknown behavior %q", name)
+	}
+	v := reflect.New(reflect.TypeOf(newBehavior()))
+	if params != nil {
+		if err := json.Unmarshal(params, v.Interface()); err != nil {
+			return fmt.Errorf("behavior %q: %w", name, err)
+		}
+	}
+	b.Behavior = v.Elem().Interface().(Behavior)
+	return nil
+}
+
+// Wander moves the monster in a random passable direction. A
+// returning monster walks home instead.
+type Wander struct{}
+
+func (Wander) Act(m *Monster) bool {
+	if m.goHome() {
+		return true
+	}
+	dirs := m.XY.Neighbors()
+	RNG.Shuffle(len(dirs), func(i, j int) {
+		dirs[i], dirs[j] = dirs[j], dirs[i]
+	})
+	for _, q := range dirs {
+		if !m.State.Blocked(q) {
+			m.State.Move(m, q)
+			return true
+		}
+	}
+	return false
+}
+
+// Chase hunts the player when seen and searches the last seen
+// position otherwise. A positive Leash stops the chase when the
+// monster is farther than Leash from its home.
+type Chase struct {
+	Leash int
+}
+
+func (c Chase) Act(m *Monster) bool {
+	if m.Mind.Mode != Hunting {
+		return false
+	}
+	if c.Leash > 0 && distance(m.XY, m.Mind.Home) > c.Leash || m.XY == m.Mind.LastSeen {
+		// Gave up or lost the track.
+		m.Mind.Remembered = false
+		return false
+	}
+	return m.step(m.Mind.LastSeen)
+}
+
+// KeepDistance steps away from the seen player when it is closer
+// than Min.
+type KeepDistance struct {
+	Min int
+}
+
+func (k KeepDistance) Act(m *Monster) bool {
+	if m.Mind.Target == nil || distance(m.XY, m.Mind.Target.Pos()) >= k.Min {
+		return false
+	}
+	return runAway(m, m.Mind.Target.Pos())
+}
+
+// Flee runs away from the seen player while the monster is fleeing.
+// The monster starts to flee when its health is below the fraction
+// Below of its maximum.
+type Flee struct {
+	Below float64
+}
+
+func (f Flee) Act(m *Monster) bool {
+	if m.Mind.Mode != Fleeing || m.Mind.Target == nil {
+		return false
+	}
+	return runAway(m, m.Mind.Target.Pos())
+}
+
+// runAway moves the monster downhill the fleeing map from the threat.
+func runAway(m *Monster, threat XY) bool {
+	opt := PathOptions{Diagonal: true, OpenDoors: m.Kind.OpensDoors}
+	none := func(XY) bool { return false }
+	flee := m.State.DijkstraMap(map[XY]float64{threat: 0}, opt).Flee(m.State.Tiles, opt, none)
+	q, ok := flee.Downhill(m.XY, true)
+	if !ok {
+		return false
+	}
+	return m.stepTo(q)
+}
+
+// Guard returns the monster to its home and keeps it there.
+type Guard struct{}
+
+func (Guard) Act(m *Monster) bool {
+	if m.XY != m.Mind.Home {
+		m.step(m.Mind.Home)
+	}
+	return true
+}
+
+// Patrol walks the monster along its waypoints. A monster without
+// waypoints patrols between its home and a random position. A
+// returning monster walks home first.
+type Patrol struct{}
+
+func (Patrol) Act(m *Monster) bool {
+	if len(m.Mind.Waypoints) == 0 {
+		m.Mind.Waypoints = []XY{m.Mind.Home, m.State.RandomPosition()}
+	}
+	if m.goHome() {
+		return true
+	}
+	if m.XY == m.Mind.Waypoints[m.Mind.Waypoint] {
+		m.Mind.Waypoint = (m.Mind.Waypoint + 1) % len(m.Mind.Waypoints)
+	}
+	if !m.step(m.Mind.Waypoints[m.Mind.Waypoint]) {
+		// Skip an unreachable waypoint.
+		m.Mind.Waypoint = (m.Mind.Waypoint + 1) % len(m.Mind.Waypoints)
+	}
+	return true
+}
@@ -0,0 +1,126 @@
+package main
+
+import "testing"
+
+// arena returns a state with an open floor of the given size and a
+// player at p.
+func arena(size int, p XY) (*State, *Player) {
+	s := NewState()
+	Rect{0, 0, size, size}.Apply(func(p XY) { s.Tiles[p] = Floor })
+	pl := NewPlayer(p, 10, s)
+	s.Add(pl)
+	return s, pl
+}
+
+func TestChaseAttacks(t *testing.T) {
+	s, p := arena(10, XY{5, 5})
+	m := NewMonster(Kinds["goblin"], XY{2, 5}, s)
+	s.Add(m)
+	attacked := false
+	s.Events.Subscribe(func(e Event) {
+		if a, ok := e.(Attacked); ok && a.Attacker == m && a.Target == p {
+			attacked = true
+		}
+	})
+	for i := 0; i < 5 && !attacked; i++ {
+		m.Update()
+	}
+	if !attacked {
+		t.Errorf("goblin at %v did not attack the player", m.XY)
+	}
+	if m.Mind.Mode != Hunting {
+		t.Errorf("Mode = %v, want Hunting", m.Mind.Mode)
+	}
+}
+
+func TestChaseRemembers(t *testing.T) {
+	s, p := arena(10, XY{5, 5})
+	m := NewMonster(Kinds["goblin"], XY{1, 1}, s)
+	s.Add(m)
+	m.perceive()
+	s.Move(p, XY{8, 8})
+	// The player is out of sight now.
+	m.Kind = &Kind{Name: "blind goblin", Behaviors: []Behavior{Chase{}}}
+	m.Update()
+	if m.Mind.Target != nil || !m.Mind.Remembered || m.XY != (XY{2, 2}) {
+		t.Errorf("goblin at %v, remembered %v, want {2 2} towards {5 5}", m.XY, m.Mind.Remembered)
+	}
+}
+
+func TestFlee(t *testing.T) {
+	s, _ := arena(10, XY{5, 5})
+	m := NewMonster(Kinds["rat"], XY{4, 5}, s)
+	m.HP = 1
+	s.Add(m)
+	m.Update()
+	if m.Mind.Mode != Fleeing || distance(m.XY, XY{5, 5}) != 2 {
+		t.Errorf("rat at %v, mode %v, want fleeing", m.XY, m.Mind.Mode)
+	}
+}
+
+func TestGuardReturnsHome(t *testing.T) {
+	s, _ := arena(20, XY{19, 19})
+	m := NewMonster(Kinds["sentry"], XY{2, 2}, s)
+	s.Add(m)
+	s.Move(m, XY{6, 2})
+	for i := 0; i < 4; i++ {
+		m.Update()
+	}
+	if m.XY != m.Mind.Home {
+		t.Errorf("sentry at %v, want home %v", m.XY, m.Mind.Home)
+	}
+}
+
+func TestModes(t *testing.T) {
+	s, p := arena(10, XY{5, 5})
+	m := NewMonster(Kinds["goblin"], XY{2, 5}, s)
+	s.Add(m)
+	m.Update()
+	if m.Mind.Mode != Hunting {
+		t.Fatalf("Mode = %v, want Hunting", m.Mind.Mode)
+	}
+	// The player vanishes; the goblin searches, gives up and returns.
+	id, _ := s.ID(p)
+	s.Remove(id)
+	modes := map[Mode]bool{}
+	var from XY
+	for i := 0; i < 20 && m.Mind.Mode != Idle; i++ {
+		from = m.XY
+		m.Update()
+		modes[m.Mind.Mode] = true
+	}
+	if !modes[Returning] || m.Mind.Mode != Idle || from != m.Mind.Home {
+		t.Errorf("goblin idled at %v, modes %v, want returning and idle at home", from, modes)
+	}
+}
+
+func TestFleeUntilSafe(t *testing.T) {
+	s, p := arena(10, XY{5, 5})
+	m := NewMonster(Kinds["rat"], XY{4, 5}, s)
+	m.HP = 1
+	s.Add(m)
+	m.Update()
+	m.HP = m.MaxHP
+	m.Update()
+	if m.Mind.Mode != Fleeing {
+		t.Errorf("healed rat mode %v, want still fleeing", m.Mind.Mode)
+	}
+	id, _ := s.ID(p)
+	s.Remove(id)
+	m.Update()
+	if m.Mind.Mode != Returning || m.Mind.Remembered {
+		t.Errorf("rat mode %v, remembered %v, want returning", m.Mind.Mode, m.Mind.Remembered)
+	}
+}
+
+func TestModeIgnoresBehaviorOrder(t *testing.T) {
+	s, _ := arena(10, XY{5, 5})
+	m := NewMonster(Kinds["sentry"], XY{2, 5}, s)
+	m.Kind = &Kind{Name: "lazy sentry", Vision: Vision{Radius: 8}, Behaviors: []Behavior{Guard{}, Chase{}}}
+	s.Add(m)
+	s.Move(m, XY{3, 5})
+	m.Update()
+	if m.Mind.Mode != Hunting || m.XY != m.Mind.Home {
+		t.Errorf("sentry at %v, mode %v, want hunting at home", m.XY, m.Mind.Mode)
+	}
+}
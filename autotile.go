@@ -0,0 +1,75 @@
+package main
+
+// Theme is a lookup table of wall glyphs indexed by the mask of
+// connected neighbor walls. The mask bits are set for the north,
+// east, south and west neighbors in that order. See connections.
+type Theme [16]rune
+
+// Tileset themes.
+var (
+	LineTheme = Theme{
+		'┼', '│', '─', '└', '│', '│', '┌', '├',
+		'─', '┘', '─', '┴', '┐', '┤', '┬', '┼',
+	}
+	DoubleTheme = Theme{
+		'╬', '║', '═', '╚', '║', '║', '╔', '╠',
+		'═', '╝', '═', '╩', '╗', '╣', '╦', '╬',
+	}
+	RoughTheme = Theme{
+		'░', '▒', '▒', '▒', '▒', '▒', '▒', '▓',
+		'▒', '▒', '▒', '▓', '▒', '▓', '▓', '▓',
+	}
+)
+
+// Themes are the tileset themes by name.
+var Themes = map[string]*Theme{
+	"line":   &LineTheme,
+	"double": &DoubleTheme,
+	"rough":  &RoughTheme,
+}
+
+// Symbol returns the symbol of the wall at p picked from its
+// neighbor walls. Walls not adjacent to any open tile are blank.
+func (th *Theme) Symbol(tiles map[XY]Tile, p XY) Symbol {
+	s := tiles[p].Symbol()
+	if m := wallMask(tiles, p); m == 0xff {
+		s.Char = ' '
+	} else {
+		s.Char = th[connections(m)]
+	}
+	return s
+}
+
+// wallMask returns the 8-neighbor mask of the walls around p. The
+// bits are set for the neighbors which are not open clockwise from
+// north: north, north-east, east and so on.
+func wallMask(tiles map[XY]Tile, p XY) int {
+	mask := 0
+	for i, q := range []XY{p.N(), p.NE(), p.E(), p.SE(), p.S(), p.SW(), p.W(), p.NW()} {
+		if !open(tiles[q]) {
+			mask |= 1 << i
+		}
+	}
+	return mask
+}
+
+// connections reduces the 8-neighbor wall mask of a wall to the mask
+// of the orthogonal neighbor walls it connects to. Two walls connect
+// if an open tile lies along the edge between them, so the walls of
+// two rooms back to back run side by side instead of crossing.
+func connections(mask int) int {
+	set := func(i int) bool { return mask&(1<<(i%8)) != 0 }
+	c := 0
+	for k := 0; k < 4; k++ {
+		i := 2 * k
+		if set(i) && !(set(i+1) && set(i+2) && set(i+6) && set(i+7)) {
+			c |= 1 << k
+		}
+	}
+	return c
+}
+
+// open reports whether the tile is passable or a closed door.
+func open(t Tile) bool {
+	return t.Passable() || t.Closed()
+}
@@ -0,0 +1,42 @@
+package main
+
+import "testing"
+
+func TestThemeSymbol(t *testing.T) {
+	// Two rooms back to back with a wall two thick between them.
+	tiles := map[XY]Tile{}
+	Rect{1, 1, 4, 4}.Apply(func(p XY) { tiles[p] = Floor })
+	Rect{6, 1, 9, 4}.Apply(func(p XY) { tiles[p] = Floor })
+	tests := []struct {
+		p    XY
+		want rune
+	}{
+		{XY{0, 0}, '┌'},
+		{XY{2, 0}, '─'},
+		{XY{0, 2}, '│'},
+		{XY{4, 0}, '┐'},
+		{XY{4, 2}, '│'},
+		{XY{5, 0}, '┌'},
+		{XY{5, 2}, '│'},
+		{XY{4, 4}, '┘'},
+		{XY{5, 4}, '└'},
+		{XY{2, 6}, ' '},
+	}
+	for _, tt := range tests {
+		if got := LineTheme.Symbol(tiles, tt.p).Char; got != tt.want {
+			t.Errorf("symbol at %v = %q, want %q", tt.p, got, tt.want)
+		}
+	}
+}
+
+func TestWallMask(t *testing.T) {
+	tiles := map[XY]Tile{{0, -1}: Floor, {1, 1}: Floor}
+	// All but the north and the south-east neighbors are walls.
+	if got := wallMask(tiles, XY{}); got != 0xff&^(1<<0|1<<3) {
+		t.Errorf("wallMask = %08b", got)
+	}
+	// Only an open north-east neighbor connects north and east.
+	if got := connections(0xff &^ (1 << 1)); got != 1<<0|1<<1 {
+		t.Errorf("connections = %04b, want north and east", got)
+	}
+}
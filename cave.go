@@ -8,13 +8,13 @@ type Cave struct {
 }
 
 // Generate generates a continuous cave map.
-func (c Cave) Generate(tiles map[XY]Tile, bounds Rect) {
+func (c Cave) Generate(tiles map[XY]Tile, bounds Area) {
 	c.Maze(tiles, bounds)
 	for i := 0; i < c.RDE1; i++ {
 		removeDeadEnds(tiles)
 	}
 	for i := 0; i < c.Grow; i++ {
-		growMap(tiles)
+		growMap(tiles, bounds)
 	}
 	for i := 0; i < c.RDE2; i++ {
 		removeDeadEnds(tiles)
@@ -0,0 +1,125 @@
+package main
+
+import "image/color"
+
+// Stats are the combat statistics of an entity.
+type Stats struct {
+	HP, MaxHP int
+	Attack    int
+	Defense   int
+}
+
+// CombatStats implements the Fighter interface.
+func (s *Stats) CombatStats() *Stats {
+	return s
+}
+
+// Fighter is implemented by any entity that can attack and be
+// attacked.
+type Fighter interface {
+	Entity
+	CombatStats() *Stats
+}
+
+// Inflicter is implemented by any fighter that applies effects to
+// the target on hit.
+type Inflicter interface {
+	Inflicts() []Effect
+}
+
+// FighterAt returns the fighter at p other than the given entity.
+func (s *State) FighterAt(p XY, other Entity) (Fighter, bool) {
+	for _, e := range s.EntitiesAt(p) {
+		if f, ok := e.(Fighter); ok && e != other {
+			return f, true
+		}
+	}
+	return nil, false
+}
+
+// Attack resolves a melee attack of a on d. The hit chance grows
+// with the attack of a over the defense of d. The damage is rolled
+// from the attack of a reduced by the defense of d, at least 1.
+// Reports whether d died.
+func (s *State) Attack(a, d Fighter) bool {
+	as, ds := a.CombatStats(), d.CombatStats()
+	chance := 0.75 + 0.05*float64(as.Attack-ds.Defense)
+	if chance > 0.95 {
+		chance = 0.95
+	}
+	if chance < 0.05 {
+		chance = 0.05
+	}
+	if RNG.Float64() >= chance {
+		s.Events.Publish(Attacked{a, d, false, 0})
+		return false
+	}
+	damage := roll(as.Attack) - roll(ds.Defense)
+	if damage < 1 {
+		damage = 1
+	}
+	ds.HP -= damage
+	s.Events.Publish(Attacked{a, d, true, damage})
+	if in, ok := a.(Inflicter); ok && ds.HP > 0 {
+		for _, ef := range in.Inflicts() {
+			s.AddEffect(d, ef)
+		}
+	}
+	if ds.HP > 0 {
+		return false
+	}
+	if p, ok := d.(*Player); ok {
+		p.KilledBy = "a " + nameOf(a)
+	}
+	s.Kill(d)
+	return true
+}
+
+// roll returns a random number from 0 to n. Effects and equipment may
+// lower a stat below 0, which rolls 0.
+func roll(n int) int {
+	if n < 0 {
+		return 0
+	}
+	return RNG.Intn(n + 1)
+}
+
+// Kill publishes the death of the entity. Entities other than the
+// player are removed from the world leaving a corpse.
+func (s *State) Kill(e Entity) {
+	id, ok := s.ID(e)
+	if !ok {
+		return
+	}
+	s.Events.Publish(Died{id, e})
+	if _, ok := e.(*Player); ok {
+		return
+	}
+	s.Remove(id)
+	s.Add(&Corpse{e.Pos(), nameOf(e)})
+	if l, ok := e.(Looter); ok {
+		for _, it := range l.Loot() {
+			s.Drop(it, e.Pos())
+		}
+	}
+}
+
+// Looter is implemented by any entity that drops items on death.
+type Looter interface {
+	Loot() []*Item
+}
+
+// Corpse is the remains of a dead entity.
+type Corpse struct {
+	XY
+	Of string
+}
+
+// Name implements the Namer interface.
+func (c *Corpse) Name() string {
+	return c.Of + " corpse"
+}
+
+func (c *Corpse) Symbol() Symbol {
+	return Symbol{color.RGBA{0x6a, 0x1a, 0x12, 0xff}, '%'}
+}
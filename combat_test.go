@@ -0,0 +1,68 @@
+package main
+
+import "testing"
+
+func TestAttackKills(t *testing.T) {
+	s := NewState()
+	p := NewPlayer(XY{0, 0}, 5, s)
+	p.Attack = 100
+	s.Add(p)
+	m := &Miner{XY{1, 0}, Rect{}, 10, s, minerStats}
+	s.Add(m)
+
+	died := false
+	s.Events.Subscribe(func(e Event) {
+		if d, ok := e.(Died); ok && d.Entity == m {
+			died = true
+		}
+	})
+	for i := 0; i < 100 && !died; i++ {
+		s.Attack(p, m)
+	}
+	if !died {
+		t.Fatalf("miner survived 100 attacks")
+	}
+	if _, ok := s.ID(m); ok {
+		t.Errorf("dead miner is in the world")
+	}
+	e := s.EntitiesAt(XY{1, 0})
+	if len(e) != 1 || nameOf(e[0]) != "miner corpse" {
+		t.Errorf("EntitiesAt({1 0}) = %v, want a miner corpse", e)
+	}
+	if _, ok := s.FighterAt(XY{1, 0}, p); ok {
+		t.Errorf("corpse is a fighter")
+	}
+}
+
+func TestPlayerDeath(t *testing.T) {
+	s := NewState()
+	p := NewPlayer(XY{0, 0}, 5, s)
+	p.HP = 1
+	s.Add(p)
+	m := &Miner{XY{1, 0}, Rect{}, 10, s, minerStats}
+	m.Attack = 100
+	s.Add(m)
+	for i := 0; i < 100 && p.HP > 0; i++ {
+		s.Attack(m, p)
+	}
+	if p.HP > 0 || p.KilledBy != "a miner" {
+		t.Errorf("HP = %d, KilledBy = %q", p.HP, p.KilledBy)
+	}
+	if _, ok := s.ID(p); !ok {
+		t.Errorf("dead player was removed")
+	}
+}
+
+func TestAttackNegativeStats(t *testing.T) {
+	s := NewState()
+	p := NewPlayer(XY{0, 0}, 5, s)
+	p.Attack, p.Defense = -5, -3
+	s.Add(p)
+	m := &Miner{XY{1, 0}, Rect{}, 10, s, minerStats}
+	m.Attack, m.Defense = -2, -7
+	s.Add(m)
+	for i := 0; i < 20; i++ {
+		s.Attack(p, m)
+		s.Attack(m, p)
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+// Action is a kind of player commands.
+type Action int
+
+const (
+	// MoveAction moves or attacks in the direction Dir. A zero Dir
+	// waits a turn.
+	MoveAction Action = iota
+	// PickUpAction picks up the items under the player.
+	PickUpAction
+	// EquipAction equips or unequips the inventory item Item.
+	EquipAction
+	// DropAction drops the inventory item Item.
+	DropAction
+	// CloseAction closes the open door in the direction Dir or all
+	// adjacent open doors if Dir is zero.
+	CloseAction
+	// DigAction digs into the wall in the direction Dir.
+	DigAction
+	// BashAction bashes the closed door in the direction Dir or the
+	// first adjacent one if Dir is zero.
+	BashAction
+	// SearchAction searches the surroundings for hidden traps.
+	SearchAction
+	// DisarmAction disarms the revealed trap in the direction Dir or
+	// the first adjacent one if Dir is zero.
+	DisarmAction
+	// PerkAction chooses the available perk Item. It takes no turn.
+	PerkAction
+)
+
+// Command is an action of the player. Commands are the only input of
+// the world and are recorded to replay the game.
+type Command struct {
+	Action Action
+	Dir    XY
+	Item   int
+}
+
+// Do performs the command. Reports whether the player spent a turn.
+func (p *Player) Do(c Command) bool {
+	switch c.Action {
+	case MoveAction:
+		return p.move(c.Dir)
+	case PickUpAction:
+		return p.PickUp()
+	case EquipAction:
+		if c.Item < 0 || c.Item >= len(p.Inventory.Items) {
+			return false
+		}
+		it := p.Inventory.Items[c.Item]
+		if it.Kind.Slot == NoSlot {
+			return false
+		}
+		if p.Equipped(it) {
+			p.Unequip(it)
+		} else {
+			p.Equip(it)
+		}
+		return true
+	case DropAction:
+		if c.Item < 0 || c.Item >= len(p.Inventory.Items) {
+			return false
+		}
+		p.Drop(c.Item)
+		return true
+	case CloseAction:
+		closed := false
+		for _, q := range p.adjacent(c.Dir) {
+			if p.State.CloseDoor(p, q) {
+				closed = true
+			}
+		}
+		return closed
+	case DigAction:
+		return p.dig(c.Dir)
+	case SearchAction:
+		p.spot(searchFactor)
+		return true
+	case DisarmAction:
+		return p.disarm(c.Dir)
+	case BashAction:
+		for _, q := range p.adjacent(c.Dir) {
+			if p.State.Tiles[q].Closed() {
+				p.State.BashDoor(p, q)
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// searchFactor multiplies the chance of spotting traps when searching.
+const searchFactor = 4
+
+// adjacent returns the point in the direction or all neighbor points
+// if the direction is zero.
+func (p *Player) adjacent(dir XY) []XY {
+	if dir == (XY{}) {
+		return p.XY.Neighbors()
+	}
+	return []XY{p.XY.Add(dir)}
+}
+
+// move moves the player by the offset attacking any fighter in the
+// way and opening closed doors. A confused player may stumble in a
+// random direction. Reports false if the player bumped into a locked
+// door without a key.
+func (p *Player) move(offset XY) bool {
+	if offset != (XY{}) && p.State.HasEffect(p, Confused) && RNG.Float64() < 0.5 {
+		offset = XY{}.Neighbors()[RNG.Intn(8)]
+	}
+	q := p.XY.Add(offset)
+	t := p.State.Tiles[q]
+	switch f, ok := p.State.FighterAt(q, p); {
+	case ok:
+		if p.State.Attack(p, f) {
+			p.Kills++
+			if r, ok := f.(Rewarder); ok {
+				p.GainXP(r.XP())
+			}
+		}
+	case t.Closed():
+		if !p.State.OpenDoor(p, q) {
+			p.State.Events.Publish(Notice{q, "The door is locked. Press B to bash it."})
+			return false
+		}
+	case t.Passable():
+		if p.State.Move(p, q) {
+			p.State.SpringTrap(p, q)
+		}
+	}
+	return true
+}
@@ -0,0 +1,66 @@
+package main
+
+// CanDig reports whether the tile at p can be dug through. The walls
+// on the border of the map are indestructible.
+func (s *State) CanDig(p XY) bool {
+	return s.Tiles[p].Diggable() && p.In(s.Bounds.Inset(1))
+}
+
+// Dig lets the entity dig through the tile at p leaving rubble and
+// some stones behind.
+func (s *State) Dig(e Entity, p XY) {
+	t := s.Tiles[p]
+	rubble, ok := t.Rubble()
+	if !ok {
+		rubble = Floor
+	}
+	s.Tiles[p] = rubble
+	s.Events.Publish(Dug{e, p, t})
+	if RNG.Float64() < 0.5 {
+		s.Drop(NewItem(Items["stone"], p, 1+RNG.Intn(2)), p)
+	}
+}
+
+// digTool returns the carried item with the highest digging effort.
+func (p *Player) digTool() (*Item, bool) {
+	var tool *Item
+	for _, it := range p.Inventory.Items {
+		if it.Kind.Dig > 0 && (tool == nil || it.Kind.Dig > tool.Kind.Dig) {
+			tool = it
+		}
+	}
+	return tool, tool != nil
+}
+
+// dig spends a turn digging into the wall in the direction. The wall
+// gives way once the effort reaches its hardness, which updates the
+// light and the field of view at once. Reports false if the player
+// cannot dig there.
+func (p *Player) dig(dir XY) bool {
+	q := p.XY.Add(dir)
+	if dir == (XY{}) || !p.State.Tiles[q].Diggable() {
+		return false
+	}
+	if !p.State.CanDig(q) {
+		p.State.Events.Publish(Notice{q, "This wall is too hard to dig."})
+		return false
+	}
+	tool, ok := p.digTool()
+	if !ok {
+		p.State.Events.Publish(Notice{q, "You need a pick-axe to dig."})
+		return false
+	}
+	if p.Digging != q {
+		p.Digging, p.DigEffort = q, 0
+		p.State.Events.Publish(Notice{q, sentence("You start digging with the %s.", tool.Kind.Name)})
+	}
+	p.DigEffort += tool.Kind.Dig + p.Effective[DigStat]
+	if p.DigEffort < p.State.Tiles[q].Hardness() {
+		return true
+	}
+	p.Digging, p.DigEffort = XY{}, 0
+	p.State.Dig(p, q)
+	p.State.UpdateLight()
+	p.UpdateFOV()
+	return true
+}
@@ -0,0 +1,42 @@
+package main
+
+import "testing"
+
+func TestDig(t *testing.T) {
+	s, p := arena(5, XY{1, 0})
+	s.Bounds = Rect{-3, -3, 8, 8}
+	wall := XY{1, -1}
+	if p.Do(Command{Action: DigAction, Dir: North}) {
+		t.Errorf("dug without a tool")
+	}
+	p.Inventory.Add(NewItem(Items["pick-axe"], p.XY, 1))
+	turns := 0
+	for s.Tiles[wall] == Wall && turns < 10 {
+		if !p.Do(Command{Action: DigAction, Dir: North}) {
+			t.Fatalf("digging took no turn")
+		}
+		turns++
+	}
+	if want := Wall.Hardness() / Items["pick-axe"].Dig; turns != want {
+		t.Errorf("dug through in %d turns, want %d", turns, want)
+	}
+	rubble, _ := TileByName("rubble")
+	if s.Tiles[wall] != rubble || !p.FOV[wall.Add(North)] {
+		t.Errorf("tile %v, FOV beyond %v, want rubble and visible", s.Tiles[wall], p.FOV[wall.Add(North)])
+	}
+	if p.Do(Command{Action: DigAction, Dir: North}) {
+		t.Errorf("dug into rubble")
+	}
+}
+
+func TestDigBorder(t *testing.T) {
+	s, p := arena(5, XY{0, 1})
+	s.Bounds = Rect{-1, -2, 6, 6}
+	p.Inventory.Add(NewItem(Items["pick-axe"], p.XY, 1))
+	if p.Do(Command{Action: DigAction, Dir: West}) || s.Tiles[XY{-1, 1}] != Wall {
+		t.Errorf("dug through the border wall")
+	}
+	if !s.CanDig(XY{0, -1}) {
+		t.Errorf("cannot dig inside the border")
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"container/heap"
+	"math"
+)
+
+// DijkstraMap holds the cost of reaching the nearest goal from every
+// reachable point.
+type DijkstraMap map[XY]float64
+
+// NewDijkstraMap returns a Dijkstra map over the tiles for the given
+// goals and their initial costs. blocked returns true if its argument
+// is impassable regardless of the tile. The AvoidEntities option is
+// ignored; use blocked instead.
+func NewDijkstraMap(tiles map[XY]Tile, goals map[XY]float64, opt PathOptions, blocked func(XY) bool) DijkstraMap {
+	passable := func(x XY) bool {
+		return walkable(tiles[x], opt) && !blocked(x)
+	}
+	dirs := []XY{North, South, West, East}
+	if opt.Diagonal {
+		dirs = append(dirs, North.Add(West), North.Add(East), South.Add(West), South.Add(East))
+	}
+
+	m := DijkstraMap{}
+	open := &pathQueue{}
+	for p, c := range goals {
+		m[p] = c
+		heap.Push(open, pathNode{p, c})
+	}
+	for open.Len() > 0 {
+		n := heap.Pop(open).(pathNode)
+		x := n.XY
+		if n.priority > m[x] {
+			continue
+		}
+		for _, dir := range dirs {
+			y := x.Add(dir)
+			if !passable(y) {
+				continue
+			}
+			step := 1.0
+			if dir.X != 0 && dir.Y != 0 {
+				if !opt.CutCorners && (!passable(x.Add(XY{X: dir.X})) || !passable(x.Add(XY{Y: dir.Y}))) {
+					continue
+				}
+				step = math.Sqrt2
+			}
+			c := m[x] + step*float64(tileCost(tiles[y]))
+			if old, ok := m[y]; ok && old <= c {
+				continue
+			}
+			m[y] = c
+			heap.Push(open, pathNode{y, c})
+		}
+	}
+	return m
+}
+
+// DijkstraMap returns a Dijkstra map over the state tiles for the
+// given goals and their initial costs.
+func (s *State) DijkstraMap(goals map[XY]float64, opt PathOptions) DijkstraMap {
+	blocked := func(XY) bool { return false }
+	if opt.AvoidEntities {
+		blocked = func(x XY) bool { _, goal := goals[x]; return !goal && len(s.at[x]) > 0 }
+	}
+	return NewDijkstraMap(s.Tiles, goals, opt, blocked)
+}
+
+// Add returns the sum of m and n multiplied by w at the points
+// present in both maps.
+func (m DijkstraMap) Add(n DijkstraMap, w float64) DijkstraMap {
+	r := DijkstraMap{}
+	for p, c := range m {
+		if d, ok := n[p]; ok {
+			r[p] = c + w*d
+		}
+	}
+	return r
+}
+
+// Scale returns m with every cost multiplied by k.
+func (m DijkstraMap) Scale(k float64) DijkstraMap {
+	r := DijkstraMap{}
+	for p, c := range m {
+		r[p] = c * k
+	}
+	return r
+}
+
+// Flee returns the inverted map which leads away from the goals of m
+// towards the farthest escape routes rather than into dead ends.
+func (m DijkstraMap) Flee(tiles map[XY]Tile, opt PathOptions, blocked func(XY) bool) DijkstraMap {
+	const k = -1.2
+	return NewDijkstraMap(tiles, m.Scale(k), opt, blocked)
+}
+
+// Downhill returns the neighbor of p with the lowest cost if it is
+// lower than the cost of p.
+func (m DijkstraMap) Downhill(p XY, diagonal bool) (XY, bool) {
+	dirs := p.Orthogonal()
+	if diagonal {
+		dirs = p.Neighbors()
+	}
+	best, ok := p, false
+	for _, q := range dirs {
+		if c, reachable := m[q]; reachable && c < m[best] {
+			best, ok = q, true
+		}
+	}
+	return best, ok
+}
+
+// Farthest returns the reachable point with the highest cost. Ties
+// are broken by the lowest Y, then X coordinates.
+func (m DijkstraMap) Farthest() XY {
+	var far XY
+	first := true
+	for p, c := range m {
+		if first || c > m[far] || c == m[far] && (p.Y < far.Y || p.Y == far.Y && p.X < far.X) {
+			far, first = p, false
+		}
+	}
+	return far
+}
@@ -0,0 +1,38 @@
+package main
+
+import "testing"
+
+func TestDijkstraMap(t *testing.T) {
+	// A corridor from (0, 0) to (9, 0).
+	tiles := map[XY]Tile{}
+	Rect{0, 0, 10, 1}.Apply(func(p XY) { tiles[p] = Floor })
+	none := func(XY) bool { return false }
+
+	m := NewDijkstraMap(tiles, map[XY]float64{{2, 0}: 0}, PathOptions{}, none)
+	if len(m) != 10 {
+		t.Fatalf("len(m) = %d, want 10", len(m))
+	}
+	if m[XY{0, 0}] != 2 || m[XY{9, 0}] != 7 {
+		t.Errorf("m = %v", m)
+	}
+	if far := m.Farthest(); far != (XY{9, 0}) {
+		t.Errorf("Farthest() = %v, want {9 0}", far)
+	}
+	if q, ok := m.Downhill(XY{5, 0}, false); !ok || q != (XY{4, 0}) {
+		t.Errorf("Downhill({5 0}) = %v, %v, want {4 0}", q, ok)
+	}
+	if _, ok := m.Downhill(XY{2, 0}, false); ok {
+		t.Errorf("Downhill from the goal")
+	}
+
+	// Fleeing from (2, 0) leads towards the far end of the corridor.
+	f := m.Flee(tiles, PathOptions{}, none)
+	if q, ok := f.Downhill(XY{3, 0}, false); !ok || q != (XY{4, 0}) {
+		t.Errorf("flee Downhill({3 0}) = %v, %v, want {4 0}", q, ok)
+	}
+
+	sum := m.Add(m.Scale(2), 1)
+	if sum[XY{9, 0}] != 21 {
+		t.Errorf("sum[{9 0}] = %v, want 21", sum[XY{9, 0}])
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+// OpenDoor lets the entity open the closed door at p. A locked door
+// opens only for a player carrying a key, which is used up. Reports
+// whether the door was opened.
+func (s *State) OpenDoor(e Entity, p XY) bool {
+	t := s.Tiles[p]
+	open, ok := t.Opened()
+	if !ok {
+		return false
+	}
+	if t.Locked() {
+		pl, ok := e.(*Player)
+		if !ok || !pl.Inventory.Use(Items["key"]) {
+			return false
+		}
+	}
+	s.Tiles[p] = open
+	s.Events.Publish(Opened{e, p, t.Locked()})
+	return true
+}
+
+// CloseDoor lets the entity close the open door at p unless something
+// lies in the doorway. Reports whether the door was closed.
+func (s *State) CloseDoor(e Entity, p XY) bool {
+	shut, ok := s.Tiles[p].Shut()
+	if !ok || len(s.at[p]) > 0 {
+		return false
+	}
+	s.Tiles[p] = shut
+	s.Events.Publish(Closed{e, p})
+	return true
+}
+
+// BashDoor lets the fighter try to break the closed door at p. The
+// chance grows with the attack of the fighter. Reports whether the
+// door was broken.
+func (s *State) BashDoor(f Fighter, p XY) bool {
+	broken, ok := s.Tiles[p].Bashed()
+	if !ok {
+		return false
+	}
+	chance := 0.1 + 0.05*float64(f.CombatStats().Attack)
+	ok = RNG.Float64() < chance
+	if ok {
+		s.Tiles[p] = broken
+	}
+	s.Events.Publish(Bashed{f, p, ok})
+	return ok
+}
+
+// Opened is published when an entity opens a door.
+type Opened struct {
+	Entity   Entity
+	XY       XY
+	Unlocked bool
+}
+
+func (e Opened) At() XY { return e.XY }
+
+// Closed is published when an entity closes a door.
+type Closed struct {
+	Entity Entity
+	XY     XY
+}
+
+func (e Closed) At() XY { return e.XY }
+
+// Bashed is published when a fighter bashes a door.
+type Bashed struct {
+	Entity Entity
+	XY     XY
+	Broken bool
+}
+
+func (e Bashed) At() XY { return e.XY }
@@ -0,0 +1,106 @@
+package main
+
+import "testing"
+
+// doorway returns an arena split by a wall at x = 3 with a door at
+// {3 2} and a player at p.
+func doorway(p XY) (*State, *Player) {
+	s, pl := arena(7, p)
+	for y := 0; y < 7; y++ {
+		delete(s.Tiles, XY{3, y})
+	}
+	s.Tiles[XY{3, 2}] = Door
+	return s, pl
+}
+
+func TestDoorOpenClose(t *testing.T) {
+	s, p := doorway(XY{2, 2})
+	open, _ := TileByName("open door")
+	if !p.Do(Command{Action: MoveAction, Dir: East}) || s.Tiles[XY{3, 2}] != open || p.XY != (XY{2, 2}) {
+		t.Fatalf("bump: tile %v, player at %v, want open door and {2 2}", s.Tiles[XY{3, 2}], p.XY)
+	}
+	if !open.Passable() || open.Opaque() {
+		t.Errorf("open door is not passable and transparent")
+	}
+
+	s.Drop(NewItem(Items["stone"], XY{3, 2}, 1), XY{3, 2})
+	if p.Do(Command{Action: CloseAction}) {
+		t.Errorf("closed a door blocked by a stone")
+	}
+	for _, e := range s.EntitiesAt(XY{3, 2}) {
+		id, _ := s.ID(e)
+		s.Remove(id)
+	}
+	if !p.Do(Command{Action: CloseAction}) || s.Tiles[XY{3, 2}] != Door {
+		t.Errorf("tile %v, want a closed door", s.Tiles[XY{3, 2}])
+	}
+	if p.Do(Command{Action: CloseAction}) {
+		t.Errorf("closed a door twice")
+	}
+}
+
+func TestDoorLocked(t *testing.T) {
+	s, p := doorway(XY{2, 2})
+	locked, _ := TileByName("locked door")
+	s.Tiles[XY{3, 2}] = locked
+	if p.Do(Command{Action: MoveAction, Dir: East}) || s.Tiles[XY{3, 2}] != locked {
+		t.Errorf("opened a locked door without a key")
+	}
+	p.Inventory.Add(NewItem(Items["key"], p.XY, 1))
+	if !p.Do(Command{Action: MoveAction, Dir: East}) || !s.Tiles[XY{3, 2}].Passable() {
+		t.Errorf("did not unlock the door with a key")
+	}
+	if len(p.Inventory.Items) != 0 {
+		t.Errorf("key was not used up")
+	}
+}
+
+func TestDoorBash(t *testing.T) {
+	s, p := doorway(XY{2, 2})
+	locked, _ := TileByName("locked door")
+	broken, _ := TileByName("broken door")
+	s.Tiles[XY{3, 2}] = locked
+	for i := 0; i < 100 && s.Tiles[XY{3, 2}] == locked; i++ {
+		if !p.Do(Command{Action: BashAction}) {
+			t.Fatalf("bashing took no turn")
+		}
+	}
+	if s.Tiles[XY{3, 2}] != broken || !broken.Passable() {
+		t.Errorf("tile %v, want a passable broken door", s.Tiles[XY{3, 2}])
+	}
+	if p.Do(Command{Action: BashAction}) {
+		t.Errorf("bashed a broken door")
+	}
+}
+
+func TestMonsterOpensDoor(t *testing.T) {
+	s, _ := doorway(XY{1, 2})
+	rat := NewMonster(Kinds["rat"], XY{5, 2}, s)
+	s.Add(rat)
+	if rat.step(XY{1, 2}) {
+		t.Errorf("rat walked through a closed door")
+	}
+	goblin := NewMonster(Kinds["goblin"], XY{5, 1}, s)
+	s.Add(goblin)
+	for i := 0; i < 3 && s.Tiles[XY{3, 2}] == Door; i++ {
+		goblin.step(XY{1, 2})
+	}
+	if s.Tiles[XY{3, 2}] == Door {
+		t.Errorf("goblin at %v did not open the door", goblin.XY)
+	}
+}
+
+func TestKeysReachable(t *testing.T) {
+	for seed := int64(1); seed <= 5; seed++ {
+		g := NewGame(seed)
+		start := map[XY]float64{g.Player.XY: 0}
+		reach := g.State.DijkstraMap(start, PathOptions{Diagonal: true, OpenDoors: true})
+		for _, e := range g.State.Entities {
+			if it, ok := e.(*Item); ok && it.Kind == Items["key"] {
+				if _, ok := reach[it.XY]; !ok {
+					t.Errorf("seed %d: key at %v is behind a locked door", seed, it.XY)
+				}
+			}
+		}
+	}
+}
@@ -1,7 +1,5 @@
 package main
 
-import "math/rand"
-
 type Dungeon struct {
 	Maze         MazeFunc
 	MaxRoomSize  XY
@@ -10,20 +8,27 @@ type Dungeon struct {
 }
 
 // Generate generates a continuous dungeon consisting of rooms and corridors.
-func (d Dungeon) Generate(tiles map[XY]Tile, bounds Rect) {
+func (d Dungeon) Generate(tiles map[XY]Tile, bounds Area) {
+	d.GenerateRooms(tiles, bounds)
+}
+
+// GenerateRooms implements the RoomGenerator interface.
+func (d Dungeon) GenerateRooms(tiles map[XY]Tile, bounds Area) []Rect {
 	// The maze is region 0, the rooms are regions [1, d.RoomAttempts).
 	regions := map[XY]int{}
+	rooms := []Rect{}
 	for i := 0; i < d.RoomAttempts; i++ {
 		if r, ok := Room(tiles, bounds, d.MaxRoomSize); ok {
 			r.Apply(func(p XY) {
 				regions[p] = i + 1
 			})
+			rooms = append(rooms, r)
 		}
 	}
 	d.Maze(tiles, bounds)
 
 	conns := findConnectors(tiles, regions, bounds)
-	rand.Shuffle(len(conns), func(i, j int) {
+	RNG.Shuffle(len(conns), func(i, j int) {
 		conns[i], conns[j] = conns[j], conns[i]
 	})
 
@@ -33,11 +38,11 @@ func (d Dungeon) Generate(tiles map[XY]Tile, bounds Rect) {
 		conn := conns[len(conns)-1]
 		conns = conns[:len(conns)-1]
 		if merged[regions[conn.a]] && merged[regions[conn.b]] &&
-			rand.Float64() > d.Sparsity {
+			RNG.Float64() > d.Sparsity {
 			continue
 		}
 		passages := []Tile{Door, Arch}
-		pass := passages[rand.Intn(len(passages))]
+		pass := passages[RNG.Intn(len(passages))]
 		tiles[conn.mid] = pass
 
 		// Make all neighbor passages equal.
@@ -52,6 +57,7 @@ func (d Dungeon) Generate(tiles map[XY]Tile, bounds Rect) {
 	}
 	for removeDeadEnds(tiles) != 0 {
 	}
+	return rooms
 }
 
 // floodFill fills all tiles of the same type connected to p with t.
@@ -78,17 +84,20 @@ func floodFill(tiles map[XY]Tile, p XY, t Tile) {
 }
 
 // Room attemps to generate a room of the specified maximum size in
-// the given bounds.
-func Room(tiles map[XY]Tile, bounds Rect, maxSize XY) (r Rect, ok bool) {
+// the given area.
+func Room(tiles map[XY]Tile, bounds Area, maxSize XY) (r Rect, ok bool) {
 	const min = 3
-	p := bounds.OddPoint()
+	p, ok := bounds.OddPoint()
+	if !ok {
+		return Rect{}, false
+	}
 	r = Rect{
 		p.X,
 		p.Y,
-		p.X + min + rand.Intn((maxSize.X-min+1)/2)*2,
-		p.Y + min + rand.Intn((maxSize.Y-min+1)/2)*2,
+		p.X + min + RNG.Intn((maxSize.X-min+1)/2)*2,
+		p.Y + min + RNG.Intn((maxSize.Y-min+1)/2)*2,
 	}
-	if !r.In(bounds) {
+	if !rectIn(r, bounds) {
 		return r, false
 	}
 
@@ -113,7 +122,7 @@ type connector struct {
 }
 
 // findConnectors returns all connectors that can be used to merge different regions.
-func findConnectors(tiles map[XY]Tile, regions map[XY]int, bounds Rect) []connector {
+func findConnectors(tiles map[XY]Tile, regions map[XY]int, bounds Area) []connector {
 	r := []connector{}
 	bounds.Apply(func(p XY) {
 		if tiles[p] == Floor {
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"sort"
+)
+
+// EffectKind is a kind of timed status effects.
+type EffectKind int
+
+const (
+	Poisoned EffectKind = iota
+	Hasted
+	Slowed
+	Blinded
+	Confused
+	Burning
+	Regenerating
+	numEffects
+)
+
+// Stacking defines how an effect combines with an active effect of
+// the same kind.
+type Stacking int
+
+const (
+	// Refresh resets the duration to the longer one.
+	Refresh Stacking = iota
+	// Extend adds the durations.
+	Extend
+	// Intensify adds the powers and resets the duration to the longer
+	// one.
+	Intensify
+)
+
+var effectData = [numEffects]struct {
+	name     string
+	color    color.RGBA
+	stacking Stacking
+	// cancels is the opposite effect removed by this one.
+	cancels EffectKind
+	mods    []Modifier
+	// damage is dealt every turn per power by the cause; negative
+	// heals.
+	damage int
+	cause  string
+}{
+	Poisoned:     {"poisoned", color.RGBA{0x6f, 0xc0, 0x3c, 0xff}, Intensify, -1, nil, 1, "poison"},
+	Hasted:       {"hasted", color.RGBA{0x3c, 0xa0, 0xd0, 0xff}, Refresh, Slowed, []Modifier{{SpeedStat, 50}}, 0, ""},
+	Slowed:       {"slowed", color.RGBA{0x80, 0x70, 0xb0, 0xff}, Refresh, Hasted, []Modifier{{SpeedStat, -50}}, 0, ""},
+	Blinded:      {"blinded", color.RGBA{0x80, 0x80, 0x80, 0xff}, Extend, -1, []Modifier{{SightStat, -15}}, 0, ""},
+	Confused:     {"confused", color.RGBA{0xc0, 0x60, 0xc0, 0xff}, Extend, -1, nil, 0, ""},
+	Burning:      {"burning", color.RGBA{0xff, 0x70, 0x20, 0xff}, Refresh, -1, nil, 2, "fire"},
+	Regenerating: {"regenerating", color.RGBA{0x60, 0xd0, 0x90, 0xff}, Refresh, -1, nil, -1, ""},
+}
+
+func (k EffectKind) String() string {
+	return effectData[k].name
+}
+
+func (k EffectKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+func (k *EffectKind) UnmarshalText(text []byte) error {
+	for i := range effectData {
+		if effectData[i].name == string(text) {
+			*k = EffectKind(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown effect %q", text)
+}
+
+// Color returns the color of the effect in the status bar.
+func (k EffectKind) Color() color.RGBA {
+	return effectData[k].color
+}
+
+// Effect is an active status effect lasting for Turns.
+type Effect struct {
+	Kind  EffectKind
+	Turns int
+	Power int
+}
+
+// Recomputer is implemented by any entity that derives its
+// statistics from modifiers.
+type Recomputer interface {
+	Recompute()
+}
+
+// AddEffect applies the effect to the entity according to the
+// stacking rule of its kind.
+func (s *State) AddEffect(e Entity, ef Effect) {
+	id, ok := s.ID(e)
+	if !ok {
+		return
+	}
+	if ef.Power < 1 {
+		ef.Power = 1
+	}
+	data := effectData[ef.Kind]
+	if data.cancels >= 0 {
+		s.removeEffect(id, data.cancels)
+	}
+	effects := s.effects[id]
+	for i := range effects {
+		old := &effects[i]
+		if old.Kind != ef.Kind {
+			continue
+		}
+		switch data.stacking {
+		case Extend:
+			old.Turns += ef.Turns
+		case Intensify:
+			old.Power += ef.Power
+			fallthrough
+		case Refresh:
+			if ef.Turns > old.Turns {
+				old.Turns = ef.Turns
+			}
+		}
+		return
+	}
+	s.effects[id] = append(effects, ef)
+	s.Events.Publish(EffectChanged{e, ef.Kind, true})
+	if r, ok := e.(Recomputer); ok {
+		r.Recompute()
+	}
+}
+
+// removeEffect removes the effect of the given kind from the entity
+// with the given ID.
+func (s *State) removeEffect(id ID, k EffectKind) {
+	effects := s.effects[id]
+	for i, ef := range effects {
+		if ef.Kind != k {
+			continue
+		}
+		s.effects[id] = append(effects[:i], effects[i+1:]...)
+		e := s.Entities[id]
+		s.Events.Publish(EffectChanged{e, k, false})
+		if r, ok := e.(Recomputer); ok {
+			r.Recompute()
+		}
+		return
+	}
+}
+
+// Effects returns the active effects of the entity.
+func (s *State) Effects(e Entity) []Effect {
+	id, ok := s.ID(e)
+	if !ok {
+		return nil
+	}
+	return s.effects[id]
+}
+
+// HasEffect reports whether the entity has an active effect of the
+// given kind.
+func (s *State) HasEffect(e Entity, k EffectKind) bool {
+	for _, ef := range s.Effects(e) {
+		if ef.Kind == k {
+			return true
+		}
+	}
+	return false
+}
+
+// EffectModifiers returns the statistic modifiers of the active
+// effects of the entity.
+func (s *State) EffectModifiers(e Entity) []Modifier {
+	mods := []Modifier{}
+	for _, ef := range s.Effects(e) {
+		mods = append(mods, effectData[ef.Kind].mods...)
+	}
+	return mods
+}
+
+// tickEffects applies the damage of the active effects and expires
+// them. Called once per turn.
+func (s *State) tickEffects() {
+	ids := make([]ID, 0, len(s.effects))
+	for id := range s.effects {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i] < ids[j]
+	})
+	for _, id := range ids {
+		for _, ef := range append([]Effect{}, s.effects[id]...) {
+			e, ok := s.Entities[id]
+			if !ok {
+				break
+			}
+			if f, ok := e.(Fighter); ok && effectData[ef.Kind].damage != 0 {
+				st := f.CombatStats()
+				st.HP -= effectData[ef.Kind].damage * ef.Power
+				if st.HP > st.MaxHP {
+					st.HP = st.MaxHP
+				}
+				if st.HP <= 0 {
+					if p, ok := e.(*Player); ok {
+						p.KilledBy = effectData[ef.Kind].cause
+					}
+					s.Kill(e)
+					break
+				}
+			}
+			s.expireEffect(id, ef.Kind)
+		}
+	}
+}
+
+// expireEffect decreases the remaining turns of the effect and
+// removes it when they run out.
+func (s *State) expireEffect(id ID, k EffectKind) {
+	for i := range s.effects[id] {
+		if ef := &s.effects[id][i]; ef.Kind == k {
+			ef.Turns--
+			if ef.Turns <= 0 {
+				s.removeEffect(id, k)
+			}
+			return
+		}
+	}
+}
+
+// EffectChanged is published when an effect starts or ends.
+type EffectChanged struct {
+	Entity Entity
+	Kind   EffectKind
+	Active bool
+}
+
+func (e EffectChanged) At() XY { return e.Entity.Pos() }
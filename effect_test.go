@@ -0,0 +1,63 @@
+package main
+
+import "testing"
+
+func TestEffectStacking(t *testing.T) {
+	s, p := arena(5, XY{2, 2})
+	s.AddEffect(p, Effect{Poisoned, 3, 1})
+	s.AddEffect(p, Effect{Poisoned, 2, 1})
+	s.AddEffect(p, Effect{Confused, 3, 1})
+	s.AddEffect(p, Effect{Confused, 2, 1})
+	want := []Effect{{Poisoned, 3, 2}, {Confused, 5, 1}}
+	got := s.Effects(p)
+	if len(got) != len(want) {
+		t.Fatalf("Effects = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Effects[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestEffectCancels(t *testing.T) {
+	s, p := arena(5, XY{2, 2})
+	s.AddEffect(p, Effect{Kind: Hasted, Turns: 5})
+	if p.Speed() != 150 {
+		t.Errorf("hasted Speed = %d, want 150", p.Speed())
+	}
+	s.AddEffect(p, Effect{Kind: Slowed, Turns: 5})
+	if s.HasEffect(p, Hasted) || p.Speed() != 50 {
+		t.Errorf("slowed Speed = %d, hasted = %v", p.Speed(), s.HasEffect(p, Hasted))
+	}
+}
+
+func TestEffectTick(t *testing.T) {
+	s, p := arena(5, XY{2, 2})
+	var events []EffectChanged
+	s.Events.Subscribe(func(e Event) {
+		if ec, ok := e.(EffectChanged); ok {
+			events = append(events, ec)
+		}
+	})
+	s.AddEffect(p, Effect{Poisoned, 3, 2})
+	for i := 0; i < 5; i++ {
+		s.tickEffects()
+	}
+	if p.HP != p.MaxHP-6 {
+		t.Errorf("HP = %d, want %d", p.HP, p.MaxHP-6)
+	}
+	if s.HasEffect(p, Poisoned) {
+		t.Errorf("poison did not expire")
+	}
+	if len(events) != 2 || !events[0].Active || events[1].Active {
+		t.Errorf("events = %v, want start and end", events)
+	}
+
+	p.HP = 1
+	s.AddEffect(p, Effect{Kind: Burning, Turns: 3})
+	s.tickEffects()
+	if p.HP > 0 || p.KilledBy != "fire" {
+		t.Errorf("HP = %d, KilledBy = %q", p.HP, p.KilledBy)
+	}
+}
@@ -7,26 +7,45 @@ import (
 // ID of a game entity.
 type ID int
 
+// nextID is the ID returned by the next call to MakeID. It is saved
+// with the game.
+var nextID ID
+
 // MakeID returns a new unique ID.
-var MakeID = func() func() ID {
-	i := ID(0)
-	return func() ID {
-		defer func() { i++ }()
-		return i
-	}
-}()
-
-// Entity is implemented by any value that has a position, a symbol,
-// and can update its state.
+func MakeID() ID {
+	defer func() { nextID++ }()
+	return nextID
+}
+
+// Entity is implemented by any value that has a position and a
+// symbol.
 type Entity interface {
 	Poser
 	Symboler
+}
+
+// Updater is implemented by any entity that acts on its turns. Only
+// updaters gain energy and are scheduled.
+type Updater interface {
 	Update()
 }
 
+// Blocker is implemented by any entity that blocks the movement of
+// other blocking entities. At most one of them occupies a point.
+type Blocker interface {
+	Blocks() bool
+}
+
+// blocks reports whether the entity blocks movement.
+func blocks(e Entity) bool {
+	b, ok := e.(Blocker)
+	return ok && b.Blocks()
+}
+
 // Poser is implemented by any value that has a position on the grid.
 type Poser interface {
 	Pos() XY
+	SetPos(XY)
 }
 
 // Symboler is implemented by any value that has a symbol and a color.
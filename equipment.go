@@ -0,0 +1,209 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// Slot is an equipment slot. Items with NoSlot cannot be equipped.
+type Slot int
+
+const (
+	NoSlot Slot = iota
+	WeaponSlot
+	ArmorSlot
+	LightSlot
+	RingSlot
+	OtherRingSlot
+	numSlots
+)
+
+var slotNames = [numSlots]string{
+	NoSlot:        "none",
+	WeaponSlot:    "weapon",
+	ArmorSlot:     "armor",
+	LightSlot:     "light",
+	RingSlot:      "ring",
+	OtherRingSlot: "ring",
+}
+
+func (s Slot) String() string {
+	return slotNames[s]
+}
+
+// Stat is a derived character statistic.
+type Stat int
+
+const (
+	AttackStat Stat = iota
+	DefenseStat
+	SightStat
+	LightStat
+	SpeedStat
+	PerceptionStat
+	StealthStat
+	DigStat
+	numStats
+)
+
+var statNames = [numStats]string{
+	AttackStat:     "Attack",
+	DefenseStat:    "Defense",
+	SightStat:      "Sight",
+	LightStat:      "Light",
+	SpeedStat:      "Speed",
+	PerceptionStat: "Perception",
+	StealthStat:    "Stealth",
+	DigStat:        "Dig",
+}
+
+func (s Stat) String() string {
+	return statNames[s]
+}
+
+func (s Stat) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+func (s *Stat) UnmarshalText(text []byte) error {
+	for i, name := range statNames {
+		if name == string(text) {
+			*s = Stat(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown stat %q", text)
+}
+
+// Attributes are the values of all derived statistics.
+type Attributes [numStats]int
+
+// Modifier adds Add to the statistic Stat.
+type Modifier struct {
+	Stat Stat
+	Add  int
+}
+
+// Apply returns the attributes with all modifiers applied in order.
+func (a Attributes) Apply(mods []Modifier) Attributes {
+	for _, m := range mods {
+		a[m.Stat] += m.Add
+	}
+	return a
+}
+
+// modifiers returns the modifier stack of the player: the equipment
+// followed by the perks and the status effects.
+func (p *Player) modifiers() []Modifier {
+	mods := []Modifier{}
+	for _, it := range p.Equipment {
+		if it != nil {
+			mods = append(mods, it.Kind.Modifiers...)
+		}
+	}
+	for _, name := range p.Perks {
+		if perk, ok := Progress.Perk(name); ok {
+			mods = append(mods, perk.Modifiers...)
+		}
+	}
+	return append(mods, p.State.EffectModifiers(p)...)
+}
+
+// Recompute updates the effective statistics of the player from the
+// base ones and the modifier stack.
+func (p *Player) Recompute() {
+	p.Effective = p.Base.Apply(p.modifiers())
+	p.Attack = p.Effective[AttackStat]
+	p.Defense = p.Effective[DefenseStat]
+	p.Vision.Radius = p.Effective[SightStat]
+	if p.Vision.Radius < 2 {
+		p.Vision.Radius = 2
+	}
+}
+
+// Equip puts on the carried item replacing the item in its slot.
+// The second ring goes to the other ring slot.
+func (p *Player) Equip(it *Item) {
+	slot := it.Kind.Slot
+	if slot == NoSlot {
+		return
+	}
+	if slot == RingSlot && p.Equipment[RingSlot] != nil && p.Equipment[OtherRingSlot] == nil {
+		slot = OtherRingSlot
+	}
+	p.Equipment[slot] = it
+	p.Recompute()
+	p.State.Events.Publish(Equipped{p, it, true})
+}
+
+// Unequip takes off the item if it is equipped.
+func (p *Player) Unequip(it *Item) {
+	for slot, own := range p.Equipment {
+		if own == it {
+			p.Equipment[slot] = nil
+			p.Recompute()
+			p.State.Events.Publish(Equipped{p, it, false})
+		}
+	}
+}
+
+// Equipped reports whether the item is equipped.
+func (p *Player) Equipped(it *Item) bool {
+	for _, own := range p.Equipment {
+		if own == it {
+			return true
+		}
+	}
+	return false
+}
+
+// Equipped is published when an entity puts on or takes off an item.
+type Equipped struct {
+	Entity Entity
+	Item   *Item
+	On     bool
+}
+
+func (e Equipped) At() XY { return e.Entity.Pos() }
+
+// CharacterScreen shows the base and effective statistics of the
+// player and the equipment.
+type CharacterScreen struct{}
+
+func (CharacterScreen) Update(g *Game) bool {
+	return !justPressed(ebiten.KeyEscape, ebiten.KeyTab)
+}
+
+func (CharacterScreen) Draw(g *Game) {
+	p := g.Player
+	g.Terminal.Text(XY{1, 0}, "Character (Esc to close)", colorNotice)
+	xp := fmt.Sprintf("XP %d", p.XP)
+	if next, ok := Progress.Next(p.Level); ok {
+		xp += fmt.Sprintf("/%d", next)
+	}
+	g.Terminal.Text(XY{1, 2}, fmt.Sprintf("HP %d/%d  Level %d  %s", p.HP, p.MaxHP, p.Level, xp), colorInfo)
+	g.Terminal.Text(XY{1, 4}, "Stat       Base  Effective", colorNotice)
+	for s := Stat(0); s < numStats; s++ {
+		c := colorInfo
+		if p.Effective[s] != p.Base[s] {
+			c = colorNotice
+		}
+		g.Terminal.Text(XY{1, 5 + int(s)},
+			fmt.Sprintf("%-10s %4d  %9d", s, p.Base[s], p.Effective[s]), c)
+	}
+	y := 6 + int(numStats)
+	g.Terminal.Text(XY{1, y}, "Equipment", colorNotice)
+	for slot := WeaponSlot; slot < numSlots; slot++ {
+		name := "-"
+		if it := p.Equipment[slot]; it != nil {
+			name = it.Name()
+		}
+		g.Terminal.Text(XY{1, y + int(slot)}, fmt.Sprintf("%-10s %s", slot, name), colorInfo)
+	}
+	y += int(numSlots) + 1
+	g.Terminal.Text(XY{1, y}, "Perks", colorNotice)
+	for i, name := range p.Perks {
+		g.Terminal.Text(XY{1, y + 1 + i}, name, colorInfo)
+	}
+}
@@ -0,0 +1,27 @@
+package main
+
+import "testing"
+
+func TestEquip(t *testing.T) {
+	s, p := arena(5, XY{2, 2})
+	for _, name := range []string{"sword", "ring of sight", "ring of sight", "bright lantern"} {
+		p.Inventory.Add(NewItem(Items[name], XY{}, 1))
+	}
+	for _, it := range p.Inventory.Items {
+		p.Equip(it)
+	}
+	if p.Attack != 9 || p.Vision.Radius != p.Base[SightStat]+10 || p.Light().Radius != 10 {
+		t.Errorf("Attack %d, sight %d, light %d", p.Attack, p.Vision.Radius, p.Light().Radius)
+	}
+	if p.Equipment[RingSlot] == nil || p.Equipment[OtherRingSlot] == nil {
+		t.Errorf("rings not in both slots: %v", p.Equipment)
+	}
+
+	p.Drop(0)
+	if p.Attack != p.Base[AttackStat] || p.Equipment[WeaponSlot] != nil {
+		t.Errorf("dropped sword still counts: attack %d", p.Attack)
+	}
+	if len(s.ItemsAt(p.XY)) != 1 {
+		t.Errorf("sword not on the floor")
+	}
+}
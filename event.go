@@ -0,0 +1,76 @@
+package main
+
+// Event is implemented by any value published on the event bus.
+type Event interface {
+	// At returns the position where the event happened.
+	At() XY
+}
+
+// Bus delivers published events to all subscribers.
+type Bus struct {
+	subscribers []func(Event)
+}
+
+// Subscribe registers f to be called with every published event.
+func (b *Bus) Subscribe(f func(Event)) {
+	b.subscribers = append(b.subscribers, f)
+}
+
+// Publish delivers the event to all subscribers in the order of
+// subscription.
+func (b *Bus) Publish(e Event) {
+	for _, f := range b.subscribers {
+		f(e)
+	}
+}
+
+// Moved is published when an entity moves.
+type Moved struct {
+	ID     ID
+	Entity Entity
+	From   XY
+}
+
+func (e Moved) At() XY { return e.Entity.Pos() }
+
+// Dug is published when an entity digs through a tile.
+type Dug struct {
+	Entity Entity
+	XY     XY
+	Tile   Tile
+}
+
+func (e Dug) At() XY { return e.XY }
+
+// Spawned is published when an entity is added to the world.
+type Spawned struct {
+	ID     ID
+	Entity Entity
+}
+
+func (e Spawned) At() XY { return e.Entity.Pos() }
+
+// Died is published when an entity dies.
+type Died struct {
+	ID     ID
+	Entity Entity
+}
+
+func (e Died) At() XY { return e.Entity.Pos() }
+
+// Notice is published to tell the player something happened at XY.
+type Notice struct {
+	XY   XY
+	Text string
+}
+
+func (e Notice) At() XY { return e.XY }
+
+// Attacked is published when an entity attacks another.
+type Attacked struct {
+	Attacker, Target Entity
+	Hit              bool
+	Damage           int
+}
+
+func (e Attacked) At() XY { return e.Target.Pos() }
@@ -0,0 +1,357 @@
+package main
+
+import "math"
+
+// FOVAlgorithm is implemented by any value that calculates the Field
+// of View from origin limited by the vision v. opaque returns true if
+// its argument cannot pass light.
+type FOVAlgorithm interface {
+	FOV(origin XY, v Vision, opaque func(XY) bool) map[XY]bool
+}
+
+// Vision configures the extent of a Field of View.
+type Vision struct {
+	Radius int
+	Shape  Shape
+	// Dir is the direction of the vision cone. The zero value means
+	// all directions.
+	Dir XY
+	// Cone is the full angle of the vision cone in radians.
+	Cone float64
+}
+
+// Shape of a vision area.
+type Shape int
+
+const (
+	CircleShape Shape = iota
+	SquareShape
+	DiamondShape
+)
+
+// Sees reports whether the point at the offset d from the origin is
+// within v.
+func (v Vision) Sees(d XY) bool {
+	r := v.Radius
+	switch v.Shape {
+	case CircleShape:
+		if d.X*d.X+d.Y*d.Y >= r*r {
+			return false
+		}
+	case SquareShape:
+		if abs(d.X) >= r || abs(d.Y) >= r {
+			return false
+		}
+	case DiamondShape:
+		if abs(d.X)+abs(d.Y) >= r {
+			return false
+		}
+	}
+	if v.Dir == (XY{}) || d == (XY{}) {
+		return true
+	}
+	dot := float64(d.X*v.Dir.X + d.Y*v.Dir.Y)
+	norm := math.Hypot(float64(d.X), float64(d.Y)) *
+		math.Hypot(float64(v.Dir.X), float64(v.Dir.Y))
+	return dot/norm >= math.Cos(v.Cone/2)-1e-9
+}
+
+// FOV calculates the circular Field of View of p given the radius r
+// using Shadowcasting.
+func (p XY) FOV(r int, opaque func(XY) bool) map[XY]bool {
+	return Shadowcasting{}.FOV(p, Vision{Radius: r}, opaque)
+}
+
+// Shadowcasting implements Symmetric Shadowcasting: the origin sees a
+// non-opaque point q if and only if q sees the origin.
+type Shadowcasting struct{}
+
+// FOV implements the FOVAlgorithm interface.
+func (Shadowcasting) FOV(origin XY, v Vision, opaque func(XY) bool) map[XY]bool {
+	points := map[XY]bool{origin: true}
+	reveal := func(q XY) {
+		if v.Sees(q.Sub(origin)) {
+			points[q] = true
+		}
+	}
+	for _, dir := range []XY{North, South, West, East} {
+		q := quadrant{origin, dir}
+		q.scan(row{1, slope{-1, 1}, slope{1, 1}}, v.Radius, opaque, reveal)
+	}
+	return points
+}
+
+// Permissive sees a point if a line from the center or a corner of
+// the origin reaches the center or a corner of the point without
+// crossing an opaque point. It sees more than Shadowcasting.
+type Permissive struct{}
+
+// FOV implements the FOVAlgorithm interface.
+func (Permissive) FOV(origin XY, v Vision, opaque func(XY) bool) map[XY]bool {
+	const c = 0.45
+	corners := [][2]float64{{0, 0}, {-c, -c}, {c, -c}, {-c, c}, {c, c}}
+	return lineFOV(origin, v, func(q XY) bool {
+		pass := func(p XY) bool { return p == origin || p == q || !opaque(p) }
+		for _, a := range corners {
+			for _, b := range corners {
+				ax, ay := float64(origin.X)+a[0], float64(origin.Y)+a[1]
+				bx, by := float64(q.X)+b[0], float64(q.Y)+b[1]
+				if trace(ax, ay, bx, by, pass) {
+					return true
+				}
+			}
+		}
+		return false
+	})
+}
+
+// DiamondWalls sees a point if the line between the centers of the
+// origin and the point misses the diamonds inscribed in the opaque
+// points in between. Walls touching diagonally can be seen through.
+type DiamondWalls struct{}
+
+// FOV implements the FOVAlgorithm interface.
+func (DiamondWalls) FOV(origin XY, v Vision, opaque func(XY) bool) map[XY]bool {
+	return lineFOV(origin, v, func(q XY) bool {
+		ax, ay, bx, by := float64(origin.X), float64(origin.Y), float64(q.X), float64(q.Y)
+		return trace(ax, ay, bx, by, func(p XY) bool {
+			return p == origin || p == q || !opaque(p) || !hitsDiamond(origin, q, p)
+		})
+	})
+}
+
+// lineFOV returns the origin and the points within v around it which
+// sees reports true for.
+func lineFOV(origin XY, v Vision, sees func(XY) bool) map[XY]bool {
+	points := map[XY]bool{origin: true}
+	Rect{-v.Radius, -v.Radius, v.Radius + 1, v.Radius + 1}.Apply(func(d XY) {
+		if d != (XY{}) && v.Sees(d) && sees(origin.Add(d)) {
+			points[origin.Add(d)] = true
+		}
+	})
+	return points
+}
+
+// trace walks the points crossed by the line from (ax, ay) to (bx, by)
+// until pass returns false. The points are centered on the integers.
+// Reports whether it got to the point containing (bx, by).
+func trace(ax, ay, bx, by float64, pass func(XY) bool) bool {
+	p := XY{int(math.Round(ax)), int(math.Round(ay))}
+	end := XY{int(math.Round(bx)), int(math.Round(by))}
+	dx, dy := bx-ax, by-ay
+	step := XY{sign(dx), sign(dy)}
+	// next returns the part of the line to the first edge crossed
+	// along one axis and between the following ones.
+	next := func(a, d float64, p, s int) (float64, float64) {
+		if s == 0 {
+			return math.Inf(1), 0
+		}
+		return math.Abs(float64(p)+0.5*float64(s)-a) / math.Abs(d), 1 / math.Abs(d)
+	}
+	tx, stepX := next(ax, dx, p.X, step.X)
+	ty, stepY := next(ay, dy, p.Y, step.Y)
+	for n := abs(end.X-p.X) + abs(end.Y-p.Y); n >= 0; n-- {
+		if !pass(p) {
+			return false
+		}
+		if p == end {
+			return true
+		}
+		if tx < ty {
+			p.X += step.X
+			tx += stepX
+		} else {
+			p.Y += step.Y
+			ty += stepY
+		}
+	}
+	return false
+}
+
+// hitsDiamond reports whether the line between the centers of a and
+// b crosses the inside of the diamond inscribed in p.
+func hitsDiamond(a, b, p XY) bool {
+	d, e := b.Sub(a), p.Sub(a)
+	ts := []float64{0, 1}
+	if d.X != 0 {
+		ts = append(ts, float64(e.X)/float64(d.X))
+	}
+	if d.Y != 0 {
+		ts = append(ts, float64(e.Y)/float64(d.Y))
+	}
+	for _, t := range ts {
+		if t < 0 || t > 1 {
+			continue
+		}
+		x, y := t*float64(d.X)-float64(e.X), t*float64(d.Y)-float64(e.Y)
+		if math.Abs(x)+math.Abs(y) < 0.5-1e-9 {
+			return true
+		}
+	}
+	return false
+}
+
+// sign returns -1, 0 or 1 depending on the sign of f.
+func sign(f float64) int {
+	switch {
+	case f < 0:
+		return -1
+	case f > 0:
+		return 1
+	}
+	return 0
+}
+
+// sights are the FOV algorithms by their names in the data files.
+var sights = map[string]FOVAlgorithm{
+	"shadowcasting": Shadowcasting{},
+	"permissive":    Permissive{},
+	"diamond walls": DiamondWalls{},
+	"wall faces":    WallFaces{Shadowcasting{}},
+}
+
+// WallFaces wraps an FOVAlgorithm to only show opaque points that
+// have a visible non-opaque orthogonal neighbor.
+type WallFaces struct {
+	FOVAlgorithm
+}
+
+// FOV implements the FOVAlgorithm interface.
+func (w WallFaces) FOV(origin XY, v Vision, opaque func(XY) bool) map[XY]bool {
+	points := w.FOVAlgorithm.FOV(origin, v, opaque)
+	hidden := []XY{}
+	for p := range points {
+		if !opaque(p) {
+			continue
+		}
+		face := false
+		for _, q := range p.Orthogonal() {
+			if points[q] && !opaque(q) {
+				face = true
+			}
+		}
+		if !face {
+			hidden = append(hidden, p)
+		}
+	}
+	for _, p := range hidden {
+		delete(points, p)
+	}
+	return points
+}
+
+// Omniscient sees every point of the area regardless of the vision
+// and opacity. Used for the full map debug view.
+type Omniscient struct {
+	Area Area
+}
+
+// FOV implements the FOVAlgorithm interface.
+func (o Omniscient) FOV(origin XY, v Vision, opaque func(XY) bool) map[XY]bool {
+	points := map[XY]bool{}
+	o.Area.Apply(func(p XY) {
+		points[p] = true
+	})
+	return points
+}
+
+// quadrant is one of the four 90° sectors around an origin centered
+// on a cardinal direction.
+type quadrant struct {
+	origin XY
+	dir    XY
+}
+
+// transform converts the point at the given depth and column
+// relative to the quadrant to an absolute position.
+func (q quadrant) transform(depth, col int) XY {
+	switch q.dir {
+	case North:
+		return XY{q.origin.X + col, q.origin.Y - depth}
+	case South:
+		return XY{q.origin.X + col, q.origin.Y + depth}
+	case East:
+		return XY{q.origin.X + depth, q.origin.Y + col}
+	default:
+		return XY{q.origin.X - depth, q.origin.Y + col}
+	}
+}
+
+// scan reveals the points of the row and recurses into the rows
+// behind it that are not in shadow.
+func (q quadrant) scan(rw row, r int, opaque func(XY) bool, reveal func(XY)) {
+	if rw.depth >= r {
+		return
+	}
+	prev, first := false, true
+	for col := rw.minCol(); col <= rw.maxCol(); col++ {
+		p := q.transform(rw.depth, col)
+		wall := opaque(p)
+		if wall || rw.symmetric(col) {
+			reveal(p)
+		}
+		if !first && prev && !wall {
+			rw.start = tileSlope(rw.depth, col)
+		}
+		if !first && !prev && wall {
+			next := rw.next()
+			next.end = tileSlope(rw.depth, col)
+			q.scan(next, r, opaque, reveal)
+		}
+		prev, first = wall, false
+	}
+	if !first && !prev {
+		q.scan(rw.next(), r, opaque, reveal)
+	}
+}
+
+// slope is the rational number n/d with d > 0.
+type slope struct {
+	n, d int
+}
+
+// tileSlope returns the slope of the left edge of the point at the
+// given depth and column.
+func tileSlope(depth, col int) slope {
+	return slope{2*col - 1, 2 * depth}
+}
+
+// row is a row of a quadrant at the given depth bounded by the start
+// and end slopes.
+type row struct {
+	depth      int
+	start, end slope
+}
+
+// minCol returns the first column of the row, rounding ties up.
+func (rw row) minCol() int {
+	s := rw.start
+	return floorDiv(2*rw.depth*s.n+s.d, 2*s.d)
+}
+
+// maxCol returns the last column of the row, rounding ties down.
+func (rw row) maxCol() int {
+	s := rw.end
+	return -floorDiv(-2*rw.depth*s.n+s.d, 2*s.d)
+}
+
+// symmetric reports whether the center of the column lies within
+// the row's slopes.
+func (rw row) symmetric(col int) bool {
+	return col*rw.start.d >= rw.depth*rw.start.n &&
+		col*rw.end.d <= rw.depth*rw.end.n
+}
+
+// next returns the row behind rw.
+func (rw row) next() row {
+	return row{rw.depth + 1, rw.start, rw.end}
+}
+
+// floorDiv returns a/b rounded towards negative infinity.
+func floorDiv(a, b int) int {
+	q := a / b
+	if (a%b != 0) && ((a < 0) != (b < 0)) {
+		q--
+	}
+	return q
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// randomTiles returns a map of the given size with walls at the
+// borders and randomly scattered pillars.
+func randomTiles(rng *rand.Rand, size int, density float64) map[XY]Tile {
+	tiles := map[XY]Tile{}
+	Rect{1, 1, size - 1, size - 1}.Apply(func(p XY) {
+		if rng.Float64() >= density {
+			tiles[p] = Floor
+		}
+	})
+	return tiles
+}
+
+func TestFOVOpen(t *testing.T) {
+	const r = 5
+	tiles := map[XY]Tile{}
+	Rect{-10, -10, 10, 10}.Apply(func(p XY) { tiles[p] = Floor })
+	fov := XY{}.FOV(r, func(p XY) bool { return tiles[p].Opaque() })
+	Rect{-10, -10, 10, 10}.Apply(func(p XY) {
+		if want := p.X*p.X+p.Y*p.Y < r*r; fov[p] != want {
+			t.Errorf("fov[%v] = %v, want %v", p, fov[p], want)
+		}
+	})
+}
+
+func TestFOVBlocked(t *testing.T) {
+	tiles := map[XY]Tile{}
+	Rect{-10, -10, 10, 10}.Apply(func(p XY) { tiles[p] = Floor })
+	Rect{-1, -2, 2, -1}.Apply(func(p XY) { tiles[p] = Wall })
+	fov := XY{}.FOV(8, func(p XY) bool { return tiles[p].Opaque() })
+	if !fov[XY{0, -2}] {
+		t.Errorf("wall face not visible")
+	}
+	if fov[XY{0, -3}] || fov[XY{0, -7}] {
+		t.Errorf("point behind the wall is visible")
+	}
+}
+
+func TestFOVSymmetric(t *testing.T) {
+	const size, r = 30, 12
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 10; i++ {
+		tiles := randomTiles(rng, size, 0.25)
+		opaque := func(p XY) bool { return tiles[p].Opaque() }
+		fovs := map[XY]map[XY]bool{}
+		for p, tile := range tiles {
+			if tile == Floor {
+				fovs[p] = p.FOV(r, opaque)
+			}
+		}
+		for a, fov := range fovs {
+			for b := range fovs {
+				if fov[b] != fovs[b][a] {
+					t.Fatalf("%v sees %v: %v, %v sees %v: %v",
+						a, b, fov[b], b, a, fovs[b][a])
+				}
+			}
+		}
+	}
+}
+
+func TestFOVAlgorithms(t *testing.T) {
+	tiles := map[XY]Tile{}
+	Rect{-10, -10, 10, 10}.Apply(func(p XY) { tiles[p] = Floor })
+	opaque := func(p XY) bool { return tiles[p].Opaque() }
+	v := Vision{Radius: 5}
+	for name, sight := range sights {
+		fov := sight.FOV(XY{}, v, opaque)
+		Rect{-10, -10, 10, 10}.Apply(func(p XY) {
+			if want := v.Sees(p); fov[p] != want {
+				t.Errorf("%s: open fov[%v] = %v, want %v", name, p, fov[p], want)
+			}
+		})
+	}
+
+	Rect{-1, -2, 2, -1}.Apply(func(p XY) { tiles[p] = Wall })
+	v.Radius = 8
+	for name, sight := range sights {
+		fov := sight.FOV(XY{}, v, opaque)
+		if !fov[XY{0, -2}] || fov[XY{0, -3}] || fov[XY{0, -7}] {
+			t.Errorf("%s: sees %v, %v, %v behind the wall, want true, false, false",
+				name, fov[XY{0, -2}], fov[XY{0, -3}], fov[XY{0, -7}])
+		}
+	}
+}
+
+func TestFOVPermissive(t *testing.T) {
+	// A pillar diagonal to the origin shadows the corner behind it
+	// only partly.
+	tiles := map[XY]Tile{}
+	Rect{-10, -10, 10, 10}.Apply(func(p XY) { tiles[p] = Floor })
+	tiles[XY{1, 1}] = Wall
+	opaque := func(p XY) bool { return tiles[p].Opaque() }
+	v := Vision{Radius: 8}
+	permissive := Permissive{}.FOV(XY{}, v, opaque)
+	if !permissive[XY{3, 2}] || permissive[XY{3, 3}] {
+		t.Errorf("permissive sees {3 2}: %v, {3 3}: %v, want true, false",
+			permissive[XY{3, 2}], permissive[XY{3, 3}])
+	}
+	for p := range (Shadowcasting{}).FOV(XY{}, v, opaque) {
+		if !permissive[p] {
+			t.Errorf("shadowcasting sees %v, permissive does not", p)
+		}
+	}
+}
+
+func TestFOVDiamondWalls(t *testing.T) {
+	// Walls touching diagonally do not block the diagonal.
+	tiles := map[XY]Tile{}
+	Rect{-10, -10, 10, 10}.Apply(func(p XY) { tiles[p] = Floor })
+	tiles[XY{1, 0}], tiles[XY{0, 1}] = Wall, Wall
+	opaque := func(p XY) bool { return tiles[p].Opaque() }
+	v := Vision{Radius: 8}
+	fov := DiamondWalls{}.FOV(XY{}, v, opaque)
+	if !fov[XY{3, 3}] || fov[XY{3, 1}] {
+		t.Errorf("diamond walls sees {3 3}: %v, {3 1}: %v, want true, false",
+			fov[XY{3, 3}], fov[XY{3, 1}])
+	}
+}
+
+func TestFOVWallFaces(t *testing.T) {
+	// A room seen from its center; the corners of the surrounding
+	// walls face no floor.
+	tiles := map[XY]Tile{}
+	Rect{-3, -3, 4, 4}.Apply(func(p XY) { tiles[p] = Floor })
+	opaque := func(p XY) bool { return tiles[p].Opaque() }
+	v := Vision{Radius: 10}
+	plain := Shadowcasting{}.FOV(XY{}, v, opaque)
+	faces := WallFaces{Shadowcasting{}}.FOV(XY{}, v, opaque)
+	if !plain[XY{4, 4}] || faces[XY{4, 4}] || !faces[XY{4, 0}] {
+		t.Errorf("wall faces sees the corner: %v, the side: %v, want false, true",
+			faces[XY{4, 4}], faces[XY{4, 0}])
+	}
+}
+
+func TestVisionSees(t *testing.T) {
+	cone := Vision{Radius: 5, Dir: East, Cone: math.Pi / 2}
+	tests := []struct {
+		v    Vision
+		d    XY
+		want bool
+	}{
+		{Vision{Radius: 5}, XY{3, 3}, true},
+		{Vision{Radius: 5}, XY{4, 3}, false},
+		{Vision{Radius: 5, Shape: SquareShape}, XY{4, 4}, true},
+		{Vision{Radius: 5, Shape: DiamondShape}, XY{3, 2}, false},
+		{cone, XY{3, 3}, true},
+		{cone, XY{2, 3}, false},
+		{cone, XY{-1, 0}, false},
+		{cone, XY{}, true},
+	}
+	for _, tt := range tests {
+		if got := tt.v.Sees(tt.d); got != tt.want {
+			t.Errorf("%+v.Sees(%v) = %v, want %v", tt.v, tt.d, got, tt.want)
+		}
+	}
+}
+
+// rayFOV is the previous FOV implementation casting a ray to every
+// point in the radius. Kept for benchmarking.
+func rayFOV(p XY, r int, opaque func(XY) bool) map[XY]bool {
+	points := map[XY]bool{}
+	for i := -r; i <= r; i++ {
+		for j := -r; j <= r; j++ {
+			if i*i+j*j < r*r {
+				for _, q := range p.Line(p.Add(XY{i, j})) {
+					points[q] = true
+					if opaque(q) {
+						break
+					}
+				}
+			}
+		}
+	}
+	return points
+}
+
+func benchmarkFOV(b *testing.B, fov func(XY, int, func(XY) bool) map[XY]bool) {
+	const size, r = 81, 20
+	tiles := randomTiles(rand.New(rand.NewSource(1)), size, 0.1)
+	opaque := func(p XY) bool { return tiles[p].Opaque() }
+	p := XY{size / 2, size / 2}
+	tiles[p] = Floor
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		fov(p, r, opaque)
+	}
+}
+
+func BenchmarkFOVShadowcasting(b *testing.B) {
+	benchmarkFOV(b, XY.FOV)
+}
+
+func BenchmarkFOVRays(b *testing.B) {
+	benchmarkFOV(b, rayFOV)
+}
+
+func BenchmarkFOVPermissive(b *testing.B) {
+	benchmarkFOV(b, func(p XY, r int, opaque func(XY) bool) map[XY]bool {
+		return Permissive{}.FOV(p, Vision{Radius: r}, opaque)
+	})
+}
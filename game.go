@@ -1,7 +1,8 @@
 package main
 
 import (
-	"image/color"
+	"errors"
+	"fmt"
 	"math"
 	"time"
 
@@ -13,13 +14,62 @@ type Game struct {
 	Terminal *Terminal
 	Player   *Player
 	Bounds   Rect
+	Depth    int
+	Theme    *Theme
+	Log      *MessageLog
+	Screen   Screen
 	Start    time.Time
+
+	// Replay records the commands of the player. Playback replays
+	// them instead of reading the keyboard if set.
+	Replay   *Replay
+	Playback *Playback
+
+	// Debug enables the debug keys.
+	Debug bool
 }
 
+// logHeight is the number of terminal rows of the message log panel.
+const logHeight = 5
+
 func (g *Game) Draw(screen *ebiten.Image) {
 	g.Terminal.Set(screen)
-	background := color.RGBA{0x15, 0x0f, 0x0a, 0xff}
-	dy, dx := g.Terminal.Dimensions.Y, g.Terminal.Dimensions.X
+	if g.Screen != nil {
+		g.Screen.Draw(g)
+		return
+	}
+	g.drawMap(XY{g.Terminal.Dimensions.X, g.Terminal.Dimensions.Y - logHeight - 1})
+	g.drawStatus(g.Terminal.Dimensions.Y - logHeight - 1)
+	for i, m := range g.Log.Last(logHeight) {
+		g.Terminal.Text(XY{1, g.Terminal.Dimensions.Y - logHeight + i}, m.String(), m.Color)
+	}
+}
+
+// drawStatus draws the status bar of the player at the row y.
+func (g *Game) drawStatus(y int) {
+	c := colorInfo
+	if g.Player.HP*4 <= g.Player.MaxHP {
+		c = colorDanger
+	}
+	g.Terminal.Text(XY{1, y}, fmt.Sprintf("HP %d/%d", g.Player.HP, g.Player.MaxHP), c)
+	g.Terminal.Text(XY{12, y}, fmt.Sprintf("Lv %d", g.Player.Level), colorInfo)
+	g.Terminal.Text(XY{18, y}, fmt.Sprintf("Depth %d", g.Depth), colorInfo)
+	g.Terminal.Text(XY{27, y}, fmt.Sprintf("Turn %d", g.State.Turn), colorInfo)
+	x := 39
+	for _, ef := range g.State.Effects(g.Player) {
+		s := fmt.Sprintf("%s(%d)", ef.Kind, ef.Turns)
+		g.Terminal.Text(XY{x, y}, s, ef.Kind.Color())
+		x += len(s) + 1
+	}
+	if g.Playback != nil {
+		g.Terminal.Text(XY{x, y}, g.Playback.String(), colorNotice)
+	}
+}
+
+// drawMap draws the map in the top left view of the given size
+// centered on the player.
+func (g *Game) drawMap(view XY) {
+	dy, dx := view.Y, view.X
 	for y := 0; y < dy; y++ {
 		for x := 0; x < dx; x++ {
 			// Dock camera to the edge of the map.
@@ -37,20 +87,26 @@ func (g *Game) Draw(screen *ebiten.Image) {
 				p.Y = y + g.Bounds.Y1 - dy
 			}
 
-			c := Cell{Bg: background}
+			c := Cell{Bg: g.State.Tiles[p].Background()}
 			if ents := g.State.EntitiesAt(p); g.Player.FOV[p] && len(ents) > 0 {
 				ent := displayedEntity(g.Start, ents).Symbol()
 				c.Fg = ent.Color
 				c.Symbol = ent.Char
 			} else {
 				tile := g.State.Tiles[p].Symbol()
+				if g.Theme != nil && g.State.Tiles[p] == Wall {
+					tile = g.Theme.Symbol(g.State.Tiles, p)
+				}
 				c.Fg = tile.Color
 				c.Symbol = tile.Char
 			}
 			op := &ebiten.DrawImageOptions{}
 			switch {
 			case g.Player.FOV[p]:
-				// Visible; draw as is.
+				// Visible; draw lit by the accumulated light.
+				if l := g.State.Light[p]; l.Lit() {
+					op.ColorM.Scale(lightGain*l.R, lightGain*l.G, lightGain*l.B, 1)
+				}
 			case g.Player.Explored[p]:
 				// Explored; draw shadowed.
 				op.ColorM.ChangeHSV(math.Pi, 0.5, 0.75)
@@ -63,21 +119,117 @@ func (g *Game) Draw(screen *ebiten.Image) {
 	}
 }
 
+// lightGain is the brightness of a cell lit by a white light of the
+// full intensity.
+const lightGain = 2
+
+// displayedEntity returns the blocking entity if there is one and
+// cycles through the other entities otherwise.
 func displayedEntity(gameStart time.Time, e []Entity) Entity {
+	for _, x := range e {
+		if blocks(x) {
+			return x
+		}
+	}
 	const period = 400
 	dt := int(time.Since(gameStart).Milliseconds())
 	return e[(dt/period)%len(e)]
 }
 
+// Notify adds the event to the message log if the player can see it.
+func (g *Game) Notify(e Event) {
+	if !g.Player.FOV[e.At()] {
+		return
+	}
+	if text, c, ok := describe(e); ok {
+		g.Log.Add(text, c)
+	}
+}
+
+// errQuit is returned by Update to quit the game.
+var errQuit = errors.New("quit")
+
 func (g *Game) Update() error {
-	g.Player.Update()
-	if g.Player.Updated {
-		g.State.Update()
-		g.Player.Updated = false
+	if s := g.Screen; s != nil {
+		// The screen may have been replaced by the one it closed on.
+		if !s.Update(g) && g.Screen == s {
+			g.Screen = nil
+		}
+		return nil
+	}
+	if g.Player.HP <= 0 {
+		return errQuit
+	}
+	// The screens which act would add commands to a playback.
+	switch {
+	case justPressed(ebiten.KeyM):
+		g.Screen = &HistoryScreen{}
+		return nil
+	case justPressed(ebiten.KeyI) && g.Playback == nil:
+		g.Screen = &InventoryScreen{}
+		return nil
+	case justPressed(ebiten.KeyTab):
+		g.Screen = CharacterScreen{}
+		return nil
+	case justPressed(ebiten.KeyP) && g.Playback == nil:
+		g.Screen = &PerkScreen{}
+		return nil
+	case justPressed(ebiten.KeyF1) && g.Debug:
+		g.Reveal()
+		return nil
+	}
+	if g.Playback != nil {
+		g.Playback.Update(g)
+	} else if c, ok := input(); ok {
+		g.Act(c)
 	}
 	return nil
 }
 
+// Act performs the command of the player and lets the world act until
+// it is the player's turn again. Commands which take effect are
+// recorded in the replay.
+func (g *Game) Act(c Command) {
+	if c.Action == PerkAction {
+		if g.Player.ChoosePerk(c.Item) {
+			g.Replay.Record(c)
+		}
+		return
+	}
+	level := g.Player.Level
+	if !g.Player.Do(c) {
+		return
+	}
+	g.Replay.Record(c)
+	if g.State.Tiles[g.Player.XY].Trap() == "pit" {
+		g.Descend()
+		g.Log.Add(fmt.Sprintf("You fall down to depth %d.", g.Depth), colorDanger)
+		return
+	}
+	id, _ := g.State.ID(g.Player)
+	g.State.Spend(id)
+	g.State.RunUntil(id)
+	g.State.UpdateLight()
+	g.Player.UpdateFOV()
+	g.Player.spot(1)
+	if g.Player.HP <= 0 {
+		g.Screen = SummaryScreen{}
+	} else if g.Player.Level > level && g.Player.PerkPoints > 0 && g.Playback == nil {
+		g.Screen = &PerkScreen{}
+	}
+}
+
+// Reveal toggles the full map debug view. It is not a command, so it
+// is neither recorded nor saved.
+func (g *Game) Reveal() {
+	if _, ok := g.Player.Sight.(Omniscient); ok {
+		g.Player.Sight = Shadowcasting{}
+	} else {
+		g.Player.Sight = Omniscient{g.Bounds}
+	}
+	g.Player.UpdateFOV()
+}
+
 func (g *Game) Layout(outsideWidth, outsideHeight int) (screenWidth, screenHeight int) {
 	return g.Terminal.Layout()
 }
@@ -1,7 +1,38 @@
 package main
 
 // Generator is implemented by any value that generates a tiled
-// structure in the given bounds.
+// structure in the given area.
 type Generator interface {
-	Generate(map[XY]Tile, Rect)
+	Generate(map[XY]Tile, Area)
+}
+
+// RoomGenerator is implemented by any generator that lays out rooms.
+// GenerateRooms generates the structure and returns its rooms.
+type RoomGenerator interface {
+	Generator
+	GenerateRooms(map[XY]Tile, Area) []Rect
+}
+
+// Area is implemented by any value that represents a set of points
+// on the grid, such as Rect or Mask.
+type Area interface {
+	// Contains reports whether p is in the area.
+	Contains(p XY) bool
+	// Apply applies f for each point in the area.
+	Apply(f func(p XY))
+	// OddPoint returns a random point from the area with odd x and y
+	// coordinates relative to the corner of its bounds. Reports false
+	// if there is none.
+	OddPoint() (XY, bool)
+}
+
+// rectIn reports whether every point of r is in a.
+func rectIn(r Rect, a Area) bool {
+	good := true
+	r.Apply(func(p XY) {
+		if !a.Contains(p) {
+			good = false
+		}
+	})
+	return good
 }
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// Inventory holds carried items limited by the number of stacks and
+// their total weight.
+type Inventory struct {
+	Items     []*Item
+	Slots     int
+	MaxWeight int
+}
+
+// Weight returns the total weight of the carried items.
+func (inv *Inventory) Weight() int {
+	w := 0
+	for _, it := range inv.Items {
+		w += it.Weight()
+	}
+	return w
+}
+
+// Add moves as many items from the stack into the inventory as the
+// limits allow. Returns the number of moved items.
+func (inv *Inventory) Add(it *Item) int {
+	n := it.Count
+	if w := it.Kind.Weight; w > 0 {
+		if fit := (inv.MaxWeight - inv.Weight()) / w; fit < n {
+			n = fit
+		}
+	}
+	if n <= 0 {
+		return 0
+	}
+	if it.Kind.Stackable {
+		for _, own := range inv.Items {
+			if own.Kind == it.Kind {
+				own.Count += n
+				it.Count -= n
+				return n
+			}
+		}
+	}
+	if len(inv.Items) >= inv.Slots {
+		return 0
+	}
+	inv.Items = append(inv.Items, &Item{Kind: it.Kind, Count: n})
+	it.Count -= n
+	return n
+}
+
+// Remove removes the stack at index i from the inventory.
+func (inv *Inventory) Remove(i int) *Item {
+	it := inv.Items[i]
+	inv.Items = append(inv.Items[:i], inv.Items[i+1:]...)
+	return it
+}
+
+// Use removes one item of the kind from the inventory. Reports false
+// if there is none.
+func (inv *Inventory) Use(k *ItemKind) bool {
+	for i, it := range inv.Items {
+		if it.Kind != k {
+			continue
+		}
+		if it.Count--; it.Count == 0 {
+			inv.Remove(i)
+		}
+		return true
+	}
+	return false
+}
+
+// PickUp moves the items lying under the player into its inventory.
+// Reports whether anything was picked up.
+func (p *Player) PickUp() bool {
+	picked := false
+	for _, it := range p.State.ItemsAt(p.XY) {
+		n := p.Inventory.Add(it)
+		if n == 0 {
+			continue
+		}
+		picked = true
+		p.State.Events.Publish(PickedUp{p, &Item{Kind: it.Kind, Count: n}})
+		if it.Count == 0 {
+			if id, ok := p.State.ID(it); ok {
+				p.State.Remove(id)
+			}
+		}
+	}
+	if !picked && len(p.State.ItemsAt(p.XY)) > 0 {
+		p.State.Events.Publish(Notice{p.XY, "Your pack is full."})
+	}
+	return picked
+}
+
+// Drop drops the stack at index i of the inventory under the player
+// taking it off first if equipped.
+func (p *Player) Drop(i int) {
+	p.Unequip(p.Inventory.Items[i])
+	it := p.Inventory.Remove(i)
+	p.State.Events.Publish(Dropped{p, it})
+	p.State.Drop(it, p.XY)
+}
+
+// InventoryScreen shows the inventory of the player and lets them
+// equip and drop items.
+type InventoryScreen struct {
+	Selected int
+}
+
+func (s *InventoryScreen) Update(g *Game) bool {
+	items := g.Player.Inventory.Items
+	switch {
+	case justPressed(ebiten.KeyEscape, ebiten.KeyI):
+		return false
+	case justPressed(ebiten.KeyUp, ebiten.KeyNumpad8):
+		s.Selected--
+	case justPressed(ebiten.KeyDown, ebiten.KeyNumpad2):
+		s.Selected++
+	case justPressed(ebiten.KeyEnter) && len(items) > 0:
+		if items[s.Selected].Kind.Slot == NoSlot {
+			break
+		}
+		g.Act(Command{Action: EquipAction, Item: s.Selected})
+		return false
+	case justPressed(ebiten.KeyR) && len(items) > 0:
+		g.Act(Command{Action: DropAction, Item: s.Selected})
+		return false
+	}
+	if s.Selected >= len(items) {
+		s.Selected = len(items) - 1
+	}
+	if s.Selected < 0 {
+		s.Selected = 0
+	}
+	return true
+}
+
+func (s *InventoryScreen) Draw(g *Game) {
+	inv := &g.Player.Inventory
+	g.Terminal.Text(XY{1, 0}, "Inventory (Enter to equip, R to drop, Esc to close)", colorNotice)
+	g.Terminal.Text(XY{1, 1}, fmt.Sprintf("Weight %d/%d, slots %d/%d",
+		inv.Weight(), inv.MaxWeight, len(inv.Items), inv.Slots), colorInfo)
+	if len(inv.Items) == 0 {
+		g.Terminal.Text(XY{1, 3}, "You carry nothing.", colorInfo)
+		return
+	}
+	for i, it := range inv.Items {
+		c := colorInfo
+		if i == s.Selected {
+			c = colorNotice
+		}
+		sym := it.Symbol()
+		g.Terminal.Print(XY{1, 3 + i}, Cell{Fg: sym.Color, Bg: colorBlack, Symbol: sym.Char}, nil)
+		name := it.Name()
+		if g.Player.Equipped(it) {
+			name += " (equipped)"
+		}
+		g.Terminal.Text(XY{3, 3 + i}, fmt.Sprintf("%c) %s", 'a'+i, name), c)
+	}
+	g.Terminal.Text(XY{1, 4 + len(inv.Items)}, inv.Items[s.Selected].Kind.Description, colorInfo)
+}
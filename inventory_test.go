@@ -0,0 +1,43 @@
+package main
+
+import "testing"
+
+func TestInventoryAdd(t *testing.T) {
+	heavy := &ItemKind{Name: "anvil", Weight: 30}
+	inv := Inventory{Slots: 2, MaxWeight: 50}
+	stones := NewItem(Items["stone"], XY{}, 12)
+	if n := inv.Add(stones); n != 12 || stones.Count != 0 {
+		t.Errorf("Add(12 stones) = %d, left %d", n, stones.Count)
+	}
+	if n := inv.Add(NewItem(Items["stone"], XY{}, 3)); n != 3 || len(inv.Items) != 1 || inv.Items[0].Count != 15 {
+		t.Errorf("stones did not stack: %d, %v", n, inv.Items)
+	}
+	if n := inv.Add(NewItem(heavy, XY{}, 1)); n != 1 {
+		t.Errorf("Add(anvil) = %d, want 1", n)
+	}
+	if n := inv.Add(NewItem(heavy, XY{}, 1)); n != 0 {
+		t.Errorf("Add(second anvil) = %d, want 0 over the weight", n)
+	}
+	if w := inv.Weight(); w != 45 {
+		t.Errorf("Weight() = %d, want 45", w)
+	}
+	if n := inv.Add(NewItem(&ItemKind{Name: "feather"}, XY{}, 1)); n != 0 {
+		t.Errorf("Add(feather) = %d, want 0 over the slots", n)
+	}
+}
+
+func TestPickUpDrop(t *testing.T) {
+	s, p := arena(5, XY{2, 2})
+	s.Drop(NewItem(Items["stone"], XY{2, 2}, 2), XY{2, 2})
+	s.Drop(NewItem(Items["stone"], XY{2, 2}, 3), XY{2, 2})
+	if items := s.ItemsAt(XY{2, 2}); len(items) != 1 || items[0].Count != 5 {
+		t.Fatalf("ItemsAt = %v, want a pile of 5", items)
+	}
+	if !p.PickUp() || len(s.ItemsAt(XY{2, 2})) != 0 || p.Inventory.Items[0].Count != 5 {
+		t.Fatalf("PickUp left %v, carries %v", s.ItemsAt(XY{2, 2}), p.Inventory.Items)
+	}
+	p.Drop(0)
+	if items := s.ItemsAt(XY{2, 2}); len(items) != 1 || items[0].Count != 5 || len(p.Inventory.Items) != 0 {
+		t.Errorf("Drop left %v, carries %v", items, p.Inventory.Items)
+	}
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+)
+
+// ItemKind describes a kind of items.
+type ItemKind struct {
+	Name        string
+	Plural      string
+	Description string
+	Symbol      Symbol
+	Weight      int
+	Stackable   bool
+	Slot        Slot
+	Modifiers   []Modifier
+	// Dig is the digging effort of the tool per turn.
+	Dig int
+}
+
+// Items are the kinds of items by name.
+var Items = map[string]*ItemKind{
+	"stone": {
+		Name:        "stone",
+		Plural:      "stones",
+		Description: "A lump of rock broken off the cave walls.",
+		Symbol:      Symbol{color.RGBA{0x45, 0x23, 0x0d, 0xff}, '●'},
+		Weight:      1,
+		Stackable:   true,
+	},
+	"key": {
+		Name:        "key",
+		Plural:      "keys",
+		Description: "An iron key. Opens a locked door once.",
+		Symbol:      Symbol{color.RGBA{0xc8, 0xa0, 0x48, 0xff}, '⚷'},
+		Weight:      1,
+		Stackable:   true,
+	},
+	"dagger": {
+		Name:        "dagger",
+		Plural:      "daggers",
+		Description: "A short blade. Attack +2.",
+		Symbol:      Symbol{color.RGBA{0xb0, 0xb8, 0xc0, 0xff}, '/'},
+		Weight:      2,
+		Slot:        WeaponSlot,
+		Modifiers:   []Modifier{{AttackStat, 2}},
+	},
+	"sword": {
+		Name:        "sword",
+		Plural:      "swords",
+		Description: "A long blade. Attack +4.",
+		Symbol:      Symbol{color.RGBA{0xd0, 0xd8, 0xe0, 0xff}, '/'},
+		Weight:      5,
+		Slot:        WeaponSlot,
+		Modifiers:   []Modifier{{AttackStat, 4}},
+	},
+	"pick-axe": {
+		Name:        "pick-axe",
+		Plural:      "pick-axes",
+		Description: "A miner's pick. Attack +1. Digs through walls with Shift and a direction.",
+		Symbol:      Symbol{color.RGBA{0xa0, 0x90, 0x80, 0xff}, '⚒'},
+		Weight:      6,
+		Slot:        WeaponSlot,
+		Modifiers:   []Modifier{{AttackStat, 1}},
+		Dig:         2,
+	},
+	"leather armor": {
+		Name:        "leather armor",
+		Plural:      "leather armors",
+		Description: "Boiled leather. Defense +2, Speed -5.",
+		Symbol:      Symbol{color.RGBA{0x8a, 0x5a, 0x2a, 0xff}, '['},
+		Weight:      8,
+		Slot:        ArmorSlot,
+		Modifiers:   []Modifier{{DefenseStat, 2}, {SpeedStat, -5}},
+	},
+	"lantern": {
+		Name:        "lantern",
+		Plural:      "lanterns",
+		Description: "An oil lantern. Light +4.",
+		Symbol:      Symbol{color.RGBA{0xff, 0xd8, 0xa0, 0xff}, '¤'},
+		Weight:      2,
+		Slot:        LightSlot,
+		Modifiers:   []Modifier{{LightStat, 4}},
+	},
+	"bright lantern": {
+		Name:        "bright lantern",
+		Plural:      "bright lanterns",
+		Description: "A lantern with a polished reflector. Light +7.",
+		Symbol:      Symbol{color.RGBA{0xff, 0xf0, 0xc0, 0xff}, '¤'},
+		Weight:      3,
+		Slot:        LightSlot,
+		Modifiers:   []Modifier{{LightStat, 7}},
+	},
+	"ring of speed": {
+		Name:        "ring of speed",
+		Plural:      "rings of speed",
+		Description: "A copper ring humming quietly. Speed +25.",
+		Symbol:      Symbol{color.RGBA{0xef, 0xac, 0x28, 0xff}, '°'},
+		Slot:        RingSlot,
+		Modifiers:   []Modifier{{SpeedStat, 25}},
+	},
+	"ring of sight": {
+		Name:        "ring of sight",
+		Plural:      "rings of sight",
+		Description: "A silver ring with a clear stone. Sight +5.",
+		Symbol:      Symbol{color.RGBA{0xc0, 0xd0, 0xff, 0xff}, '°'},
+		Slot:        RingSlot,
+		Modifiers:   []Modifier{{SightStat, 5}},
+	},
+}
+
+// Item is a stack of items of the same kind, either lying on the
+// map or carried in an inventory.
+type Item struct {
+	XY
+	Kind  *ItemKind
+	Count int
+}
+
+// NewItem returns a stack of n items of the given kind at p.
+func NewItem(k *ItemKind, p XY, n int) *Item {
+	return &Item{p, k, n}
+}
+
+// Name implements the Namer interface.
+func (it *Item) Name() string {
+	if it.Count == 1 {
+		return it.Kind.Name
+	}
+	return fmt.Sprintf("%d %s", it.Count, it.Kind.Plural)
+}
+
+func (it *Item) Symbol() Symbol {
+	return it.Kind.Symbol
+}
+
+// Weight returns the total weight of the stack.
+func (it *Item) Weight() int {
+	return it.Count * it.Kind.Weight
+}
+
+// ItemsAt returns the items lying at p sorted by ID in increasing
+// order.
+func (s *State) ItemsAt(p XY) []*Item {
+	items := []*Item{}
+	for _, e := range s.EntitiesAt(p) {
+		if it, ok := e.(*Item); ok {
+			items = append(items, it)
+		}
+	}
+	return items
+}
+
+// Drop puts the item on the map at p merging it with a pile of the
+// same kind lying there.
+func (s *State) Drop(it *Item, p XY) {
+	if it.Kind.Stackable {
+		for _, pile := range s.ItemsAt(p) {
+			if pile.Kind == it.Kind {
+				pile.Count += it.Count
+				return
+			}
+		}
+	}
+	it.XY = p
+	s.Add(it)
+}
+
+// PickedUp is published when an entity picks up items.
+type PickedUp struct {
+	Entity Entity
+	Item   *Item
+}
+
+func (e PickedUp) At() XY { return e.Entity.Pos() }
+
+// Dropped is published when an entity drops items.
+type Dropped struct {
+	Entity Entity
+	Item   *Item
+}
+
+func (e Dropped) At() XY { return e.Entity.Pos() }
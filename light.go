@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"image/color"
+	"math"
+)
+
+// Light describes a light source.
+type Light struct {
+	Color  color.RGBA
+	Radius int
+	// Falloff is the exponent of the intensity decrease with the
+	// distance from the source.
+	Falloff float64
+}
+
+func (l *Light) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Color   hexColor `json:"color"`
+		Radius  int      `json:"radius"`
+		Falloff float64  `json:"falloff"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*l = Light{color.RGBA(v.Color), v.Radius, v.Falloff}
+	return nil
+}
+
+// Emitter is implemented by any entity that emits light.
+type Emitter interface {
+	Light() Light
+}
+
+// Luminance is the amount of light per color channel, where 1 is the
+// full intensity of a white light.
+type Luminance struct {
+	R, G, B float64
+}
+
+// Lit reports whether there is enough light to see.
+func (l Luminance) Lit() bool {
+	return l.R+l.G+l.B > 0.05
+}
+
+// Lightmap holds the accumulated light of every lit point.
+type Lightmap map[XY]Luminance
+
+// Add adds the light l emitted from p. opaque returns true if its
+// argument cannot pass light.
+func (m Lightmap) Add(p XY, l Light, opaque func(XY) bool) {
+	if l.Radius <= 0 {
+		return
+	}
+	r := float64(l.Radius)
+	for q := range p.FOV(l.Radius, opaque) {
+		d := q.Sub(p)
+		k := math.Pow(1-math.Hypot(float64(d.X), float64(d.Y))/r, l.Falloff)
+		lum := m[q]
+		lum.R += k * float64(l.Color.R) / 0xff
+		lum.G += k * float64(l.Color.G) / 0xff
+		lum.B += k * float64(l.Color.B) / 0xff
+		m[q] = lum
+	}
+}
+
+// UpdateLight recalculates the light emitted by entities and tiles.
+func (s *State) UpdateLight() {
+	opaque := func(p XY) bool { return s.Tiles[p].Opaque() }
+	s.Light = Lightmap{}
+	// Sum the lights in a fixed order to keep the game deterministic.
+	for _, id := range s.sortedIDs() {
+		e := s.Entities[id]
+		if em, ok := e.(Emitter); ok {
+			s.Light.Add(e.Pos(), em.Light(), opaque)
+		}
+	}
+	lit := []XY{}
+	for p, t := range s.Tiles {
+		if t.Def().Light != nil {
+			lit = append(lit, p)
+		}
+	}
+	sortPoints(lit)
+	for _, p := range lit {
+		s.Light.Add(p, *s.Tiles[p].Def().Light, opaque)
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"image/color"
+	"math"
+	"testing"
+)
+
+var (
+	white = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	red   = color.RGBA{0xff, 0, 0, 0xff}
+	blue  = color.RGBA{0, 0, 0xff, 0xff}
+)
+
+func TestLightmapAdd(t *testing.T) {
+	type source struct {
+		p XY
+		l Light
+	}
+	tests := []struct {
+		name    string
+		sources []source
+		walls   []XY
+		at      XY
+		want    Luminance
+	}{
+		{"source", []source{{XY{}, Light{white, 5, 1}}}, nil, XY{}, Luminance{1, 1, 1}},
+		{"linear", []source{{XY{}, Light{white, 5, 1}}}, nil, XY{3, 0}, Luminance{0.4, 0.4, 0.4}},
+		{"quadratic", []source{{XY{}, Light{white, 5, 2}}}, nil, XY{3, 0}, Luminance{0.16, 0.16, 0.16}},
+		{"diagonal", []source{{XY{}, Light{white, 5, 1}}}, nil, XY{3, 4}, Luminance{}},
+		{"radius", []source{{XY{}, Light{white, 5, 1}}}, nil, XY{5, 0}, Luminance{}},
+		{"no radius", []source{{XY{}, Light{white, 0, 1}}}, nil, XY{}, Luminance{}},
+		{"wall", []source{{XY{}, Light{white, 5, 1}}}, []XY{{2, 0}}, XY{2, 0}, Luminance{0.6, 0.6, 0.6}},
+		{"shadow", []source{{XY{}, Light{white, 5, 1}}}, []XY{{2, 0}}, XY{3, 0}, Luminance{}},
+		{"sum", []source{{XY{}, Light{red, 5, 1}}, {XY{4, 0}, Light{blue, 5, 1}}}, nil, XY{2, 0}, Luminance{0.6, 0, 0.6}},
+		{"same color", []source{{XY{}, Light{red, 5, 1}}, {XY{4, 0}, Light{red, 5, 1}}}, nil, XY{1, 0}, Luminance{0.8 + 0.4, 0, 0}},
+	}
+	for _, tt := range tests {
+		tiles := map[XY]Tile{}
+		Rect{-10, -10, 10, 10}.Apply(func(p XY) { tiles[p] = Floor })
+		for _, p := range tt.walls {
+			tiles[p] = Wall
+		}
+		m := Lightmap{}
+		for _, s := range tt.sources {
+			m.Add(s.p, s.l, func(p XY) bool { return tiles[p].Opaque() })
+		}
+		got := m[tt.at]
+		if math.Abs(got.R-tt.want.R) > 1e-9 || math.Abs(got.G-tt.want.G) > 1e-9 || math.Abs(got.B-tt.want.B) > 1e-9 {
+			t.Errorf("%s: light at %v = %+v, want %+v", tt.name, tt.at, got, tt.want)
+		}
+	}
+}
+
+// lamp is an entity emitting a light.
+type lamp struct {
+	XY
+	light Light
+}
+
+func (l *lamp) Symbol() Symbol { return Symbol{} }
+func (l *lamp) Light() Light   { return l.light }
+
+func TestUpdateLight(t *testing.T) {
+	s := NewState()
+	Rect{-10, -10, 10, 10}.Apply(func(p XY) { s.Tiles[p] = Floor })
+	fungus, ok := TileByName("fungus")
+	if !ok {
+		t.Fatal("no fungus tile")
+	}
+	s.Tiles[XY{4, 0}] = fungus
+	s.Add(&lamp{XY{}, Light{white, 5, 1}})
+	s.UpdateLight()
+
+	want := Lightmap{}
+	opaque := func(p XY) bool { return s.Tiles[p].Opaque() }
+	want.Add(XY{}, Light{white, 5, 1}, opaque)
+	want.Add(XY{4, 0}, *fungus.Def().Light, opaque)
+	for _, p := range []XY{{}, {2, 0}, {4, 0}, {6, 0}, {-4, 0}} {
+		if got := s.Light[p]; got != want[p] {
+			t.Errorf("light at %v = %+v, want %+v", p, got, want[p])
+		}
+	}
+	if !s.Light[XY{6, 0}].Lit() || s.Light[XY{-8, 0}].Lit() {
+		t.Errorf("the fungus does not reach {6 0} or the lamp reaches {-8 0}")
+	}
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"strings"
+)
+
+// Message is an entry of the message log. Count is the number of
+// times it was repeated in a row.
+type Message struct {
+	Text  string
+	Color color.RGBA
+	Count int
+}
+
+// String returns the text of the message with the repeat count.
+func (m Message) String() string {
+	if m.Count > 1 {
+		return fmt.Sprintf("%s x%d", m.Text, m.Count)
+	}
+	return m.Text
+}
+
+// MessageLog holds the history of messages.
+type MessageLog struct {
+	Messages []Message
+}
+
+// Add adds a message to the log collapsing it with the last one if
+// they are equal.
+func (l *MessageLog) Add(text string, c color.RGBA) {
+	if n := len(l.Messages); n > 0 {
+		if last := &l.Messages[n-1]; last.Text == text && last.Color == c {
+			last.Count++
+			return
+		}
+	}
+	l.Messages = append(l.Messages, Message{text, c, 1})
+}
+
+// Last returns at most n last messages.
+func (l *MessageLog) Last(n int) []Message {
+	if n > len(l.Messages) {
+		n = len(l.Messages)
+	}
+	return l.Messages[len(l.Messages)-n:]
+}
+
+// Message colors.
+var (
+	colorInfo   = color.RGBA{0xa5, 0x94, 0x7a, 0xff}
+	colorNotice = color.RGBA{0xef, 0xac, 0x28, 0xff}
+	colorDanger = color.RGBA{0xd0, 0x46, 0x3c, 0xff}
+	colorBlack  = color.RGBA{A: 0xff}
+)
+
+// Namer is implemented by any entity that has a name.
+type Namer interface {
+	Name() string
+}
+
+// nameOf returns the name of the entity.
+func nameOf(e Entity) string {
+	if n, ok := e.(Namer); ok {
+		return n.Name()
+	}
+	return "something"
+}
+
+// the returns the name of the entity with the definite article, or
+// "you" for the player.
+func the(e Entity) string {
+	if _, ok := e.(*Player); ok {
+		return "you"
+	}
+	if _, ok := e.(Namer); ok {
+		return "the " + nameOf(e)
+	}
+	return "something"
+}
+
+// act returns the name of the entity with the verb conjugated to
+// agree with it, e.g. "you hit" or "the miner hits".
+func act(e Entity, verb string) string {
+	if _, ok := e.(*Player); ok {
+		return "you " + verb
+	}
+	if strings.HasSuffix(verb, "s") || strings.HasSuffix(verb, "sh") || strings.HasSuffix(verb, "ch") {
+		return the(e) + " " + verb + "es"
+	}
+	return the(e) + " " + verb + "s"
+}
+
+// is returns the name of the entity followed by the agreeing form of
+// "to be".
+func is(e Entity) string {
+	if _, ok := e.(*Player); ok {
+		return "you are"
+	}
+	return the(e) + " is"
+}
+
+// sentence capitalizes the first letter of the formatted string.
+func sentence(format string, a ...any) string {
+	s := fmt.Sprintf(format, a...)
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// describe returns the log message of the event. Reports false if the
+// event is not worth a message.
+func describe(e Event) (string, color.RGBA, bool) {
+	switch e := e.(type) {
+	case Dug:
+		return sentence("%s through the %s.", act(e.Entity, "dig"), e.Tile.Def().Name), colorInfo, true
+	case Spawned:
+		// Only creatures appear; items and corpses are merely added.
+		if _, ok := e.Entity.(Fighter); !ok {
+			break
+		}
+		if n, ok := e.Entity.(Namer); ok {
+			return sentence("a %s appears.", n.Name()), colorInfo, true
+		}
+	case Died:
+		return sentence("%s.", act(e.Entity, "die")), colorNotice, true
+	case PickedUp:
+		return sentence("%s up %s.", act(e.Entity, "pick"), e.Item.Name()), colorInfo, true
+	case Dropped:
+		return sentence("%s %s.", act(e.Entity, "drop"), e.Item.Name()), colorInfo, true
+	case Equipped:
+		if e.On {
+			return sentence("%s on %s.", act(e.Entity, "put"), e.Item.Name()), colorInfo, true
+		}
+		return sentence("%s off %s.", act(e.Entity, "take"), e.Item.Name()), colorInfo, true
+	case EffectChanged:
+		if e.Active {
+			return sentence("%s %s.", is(e.Entity), e.Kind), colorNotice, true
+		}
+		return sentence("%s no longer %s.", is(e.Entity), e.Kind), colorInfo, true
+	case Opened:
+		if e.Unlocked {
+			return sentence("%s the door.", act(e.Entity, "unlock")), colorInfo, true
+		}
+		return sentence("%s the door.", act(e.Entity, "open")), colorInfo, true
+	case Closed:
+		return sentence("%s the door.", act(e.Entity, "close")), colorInfo, true
+	case Bashed:
+		if e.Broken {
+			return sentence("%s the door open.", act(e.Entity, "break")), colorNotice, true
+		}
+		return sentence("%s the door.", act(e.Entity, "bash")), colorInfo, true
+	case Sprung:
+		return sentence("%s off the %s!", act(e.Entity, "set"), e.Tile.Def().Name), colorDanger, true
+	case Spotted:
+		return sentence("%s a %s.", act(e.Entity, "notice"), e.Tile.Def().Name), colorNotice, true
+	case Disarmed:
+		if e.OK {
+			return sentence("%s the %s.", act(e.Entity, "disarm"), e.Tile.Def().Name), colorInfo, true
+		}
+		return sentence("%s to disarm the %s.", act(e.Entity, "fail"), e.Tile.Def().Name), colorNotice, true
+	case LeveledUp:
+		return sentence("%s level %d.", act(e.Entity, "reach"), e.Level), colorNotice, true
+	case Notice:
+		return e.Text, colorNotice, true
+	case Attacked:
+		if !e.Hit {
+			return sentence("%s %s.", act(e.Attacker, "miss"), the(e.Target)), colorInfo, true
+		}
+		return sentence("%s %s for %d.", act(e.Attacker, "hit"), the(e.Target), e.Damage), colorDanger, true
+	}
+	return "", color.RGBA{}, false
+}
@@ -0,0 +1,60 @@
+package main
+
+import "testing"
+
+func TestMessageLog(t *testing.T) {
+	l := &MessageLog{}
+	l.Add("a", colorInfo)
+	l.Add("b", colorInfo)
+	l.Add("b", colorInfo)
+	l.Add("b", colorInfo)
+	l.Add("b", colorDanger)
+	if len(l.Messages) != 3 {
+		t.Fatalf("len(Messages) = %d, want 3", len(l.Messages))
+	}
+	if s := l.Messages[1].String(); s != "b x3" {
+		t.Errorf("Messages[1] = %q, want %q", s, "b x3")
+	}
+	if last := l.Last(10); len(last) != 3 {
+		t.Errorf("len(Last(10)) = %d, want 3", len(last))
+	}
+}
+
+func TestEventMessages(t *testing.T) {
+	s := NewState()
+	var got []string
+	s.Events.Subscribe(func(e Event) {
+		if text, _, ok := describe(e); ok {
+			got = append(got, text)
+		}
+	})
+	m := &Miner{XY{1, 1}, Rect{0, 0, 3, 3}, 1, s, minerStats}
+	s.Add(m)
+	s.Drop(NewItem(Items["stone"], XY{1, 1}, 3), XY{1, 1})
+	s.Add(&Corpse{XY{1, 1}, "rat"})
+	s.Move(m, XY{1, 2})
+	s.Events.Publish(Dug{m, XY{1, 2}, Wall})
+	want := []string{"A miner appears.", "The miner digs through the wall."}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("messages = %q, want %q", got, want)
+	}
+}
+
+func TestAct(t *testing.T) {
+	s := NewState()
+	p := NewPlayer(XY{}, 1, s)
+	m := &Miner{State: s}
+	for _, tt := range []struct {
+		e    Entity
+		verb string
+		want string
+	}{
+		{p, "miss", "you miss"},
+		{m, "miss", "the miner misses"},
+		{m, "hit", "the miner hits"},
+	} {
+		if got := act(tt.e, tt.verb); got != tt.want {
+			t.Errorf("act(%q) = %q, want %q", tt.verb, got, tt.want)
+		}
+	}
+}
@@ -1,60 +1,203 @@
 package main
 
 import (
+	"flag"
 	"log"
-	"math/rand"
+	"os"
 	"time"
 
 	"github.com/hajimehoshi/ebiten/v2"
 )
 
-func init() {
-	rand.Seed(time.Now().UnixNano())
+func main() {
+	if len(os.Args) > 1 && os.Args[1] == "replay" {
+		replayMain(os.Args[2:])
+		return
+	}
+	debug := flag.Bool("debug", false, "enable the debug keys, F1 reveals the map")
+	flag.Parse()
+
+	game := NewGame(time.Now().UnixNano())
+	game.Debug = *debug
+	if _, err := os.Stat(savePath); err == nil {
+		game.Screen = ContinueScreen{}
+	}
+	run(game)
+
+	// Save the game on quit unless the player is dead.
+	if game.Player.HP <= 0 {
+		os.Remove(savePath)
+	} else if err := game.SaveFile(savePath); err != nil {
+		log.Fatal(err)
+	}
+
+	// A game continued from a save without a replay cannot be replayed.
+	if game.Replay == nil {
+		return
+	}
+	game.Replay.Hash = game.Hash()
+	if err := game.Replay.WriteFile(replayPath); err != nil {
+		log.Fatal(err)
+	}
 }
 
-func main() {
-	// Initialize the game.
+// NewGame returns a new game generated from the seed. The same seed
+// always generates the same game.
+func NewGame(seed int64) *Game {
+	RNG.Seed(seed)
+	nextID = 0
 	game := &Game{
-		State: NewState(),
-		Terminal: &Terminal{
-			TileSize:   XY{6, 8},
-			Dimensions: XY{81, 61},
-			Font:       ParseFont(fontData, 8),
-		},
+		State:  NewState(),
 		Bounds: Rect{0, 0, 81, 81},
+		Log:    &MessageLog{},
 		Start:  time.Now(),
+		Replay: &Replay{Version: replayVersion, Seed: seed},
 	}
 
+	// Create the player with a lantern.
+	game.Player = NewPlayer(XY{}, 20, game.State)
+	lantern := NewItem(Items["lantern"], XY{}, 1)
+	game.Player.Inventory.Add(lantern)
+	game.Player.Equip(game.Player.Inventory.Items[0])
+
+	game.Descend()
+	game.Log.Add("You enter the cave. Press M for the message history.", colorNotice)
+	return game
+}
+
+// Descend generates the next level and moves the player there. The
+// player keeps the inventory and the status effects.
+func (g *Game) Descend() {
+	effects := g.State.Effects(g.Player)
+	g.Depth++
+	g.State = NewState()
+	g.State.Bounds = g.Bounds
+
 	// Generate a map.
-	gens := []Generator{
-		Dungeon{MazeDFS, XY{15, 15}, 100, 0.02},
-		Cave{MazeDFS, 400, 2, 3},
-		Cave{MazePrim, 7, 3, 3},
+	levels := []struct {
+		name  string
+		gen   Generator
+		theme *Theme
+	}{
+		{"dungeon", Dungeon{MazeDFS, XY{15, 15}, 100, 0.02}, &DoubleTheme},
+		{"cave", Cave{MazeDFS, 400, 2, 3}, &RoughTheme},
+		// The narrow tunnels of this cave read better as lines.
+		{"cave", Cave{MazePrim, 7, 3, 3}, &LineTheme},
 	}
-	gens[rand.Intn(len(gens))].Generate(game.State.Tiles, game.Bounds)
+	level := levels[RNG.Intn(len(levels))]
+	var rooms []Rect
+	if rg, ok := level.gen.(RoomGenerator); ok {
+		rooms = rg.GenerateRooms(g.State.Tiles, g.Bounds)
+	} else {
+		level.gen.Generate(g.State.Tiles, g.Bounds)
+	}
+	g.Theme = level.theme
+
+	// Grow some glowing fungus.
+	if fungus, ok := TileByName("fungus"); ok {
+		for i := 0; i < 20; i++ {
+			g.State.Tiles[g.State.RandomPosition()] = fungus
+		}
+	}
+
+	// Hide some traps.
+	PlaceTraps(g.State.Tiles, rooms, 4+g.Depth)
 
 	// Add the player.
-	game.Player = NewPlayer(game.State.RandomPosition(), 20, game.State)
-	game.State.Add(game.Player)
+	pl := g.Player
+	pl.State = g.State
+	pl.XY = g.State.RandomPosition()
+	pl.Explored = map[XY]bool{}
+	pl.Digging, pl.DigEffort = XY{}, 0
+	g.State.Add(pl)
+	for _, ef := range effects {
+		g.State.AddEffect(pl, ef)
+	}
+
+	// Lock some doors and hide a key for each of them.
+	if locked, ok := TileByName("locked door"); ok {
+		doors := []XY{}
+		for p, t := range g.State.Tiles {
+			if t == Door {
+				doors = append(doors, p)
+			}
+		}
+		sortPoints(doors)
+		keys := 0
+		for _, p := range doors {
+			if RNG.Float64() < 0.2 {
+				g.State.Tiles[p] = locked
+				keys++
+			}
+		}
+
+		// The keys lie where the player can go without a key.
+		start := map[XY]float64{pl.XY: 0}
+		opt := PathOptions{Diagonal: true, OpenDoors: true}
+		free := []XY{}
+		for p := range g.State.DijkstraMap(start, opt) {
+			if g.State.Tiles[p] == Floor && len(g.State.EntitiesAt(p)) == 0 {
+				free = append(free, p)
+			}
+		}
+		sortPoints(free)
+		for i := 0; i < keys && len(free) > 0; i++ {
+			q := free[RNG.Intn(len(free))]
+			g.State.Drop(NewItem(Items["key"], q, 1), q)
+		}
+	}
 
-	// Add some monsters.
-	for i := 0; i < 0; i++ {
-		game.State.Add(&Miner{
-			XY:     game.State.RandomPosition(),
-			Bounds: game.Bounds.Inset(1),
-			Energy: rand.Intn(1000),
-			State:  game.State,
-		})
+	// Place the stairs at the farthest point from the player reachable
+	// without keys.
+	if stairs, ok := TileByName("stairs"); ok {
+		start := map[XY]float64{pl.XY: 0}
+		opt := PathOptions{Diagonal: true, OpenDoors: true}
+		far := g.State.DijkstraMap(start, opt).Farthest()
+		g.State.Tiles[far] = stairs
 	}
 
-	// Run the game.
+	// Scatter some items.
+	for i := 0; i < 6; i++ {
+		p := g.State.RandomPosition()
+		g.State.Drop(NewItem(Items["stone"], p, 1+RNG.Intn(5)), p)
+	}
+	for _, name := range []string{"dagger", "sword", "leather armor", "bright lantern", "ring of speed", "ring of sight", "pick-axe"} {
+		p := g.State.RandomPosition()
+		g.State.Drop(NewItem(Items[name], p, 1), p)
+	}
+
+	// Add the monsters from the spawn table.
+	Spawns.Populate(g.State, &Level{
+		Depth:     g.Depth,
+		Generator: level.name,
+		Bounds:    g.Bounds,
+		Rooms:     Spawns.TagRooms(rooms),
+	}, pl.XY)
+
+	// Report the events seen by the player.
+	g.State.Events.Subscribe(g.Notify)
+
+	// Let the world act until the first turn of the player.
+	id, _ := g.State.ID(pl)
+	g.State.RunUntil(id)
+	g.State.UpdateLight()
+	pl.UpdateFOV()
+}
+
+// run opens the window and runs the game until it is closed.
+func run(game *Game) {
+	game.Terminal = &Terminal{
+		TileSize:   XY{6, 8},
+		Dimensions: XY{81, 61},
+		Font:       ParseFont(fontData, 8),
+	}
 	width, height := game.Layout(0, 0)
 	ebiten.SetWindowSize(2*width, 2*height)
 	ebiten.SetCursorMode(ebiten.CursorModeCaptured)
 	ebiten.SetFPSMode(ebiten.FPSModeVsyncOffMaximum)
 	ebiten.SetWindowTitle("Cave")
 	ebiten.SetTPS(12)
-	if err := ebiten.RunGame(game); err != nil {
+	if err := ebiten.RunGame(game); err != nil && err != errQuit {
 		log.Fatal(err)
 	}
 }
@@ -0,0 +1,114 @@
+package main
+
+// Mask represents an arbitrary set of points on the grid. The zero
+// value is an empty mask.
+type Mask struct {
+	points map[XY]bool
+	// odd caches the points returned by OddPoint. It is reset when a
+	// point is added.
+	odd []XY
+}
+
+// NewMask returns a mask of the given points.
+func NewMask(points ...XY) *Mask {
+	m := &Mask{}
+	for _, p := range points {
+		m.Add(p)
+	}
+	return m
+}
+
+// Circle returns a mask of all points within the radius r of c.
+func Circle(c XY, r int) *Mask {
+	m := &Mask{}
+	Rect{c.X - r, c.Y - r, c.X + r + 1, c.Y + r + 1}.Apply(func(p XY) {
+		if d := p.Sub(c); d.X*d.X+d.Y*d.Y <= r*r {
+			m.Add(p)
+		}
+	})
+	return m
+}
+
+// Add adds p to m.
+func (m *Mask) Add(p XY) {
+	if m.points == nil {
+		m.points = map[XY]bool{}
+	}
+	m.points[p] = true
+	m.odd = nil
+}
+
+// Len returns the number of points in m.
+func (m *Mask) Len() int {
+	return len(m.points)
+}
+
+// Contains reports whether p is in m.
+func (m *Mask) Contains(p XY) bool {
+	return m.points[p]
+}
+
+// Bounds returns the smallest rectangle containing every point of m.
+func (m *Mask) Bounds() Rect {
+	first := true
+	var r Rect
+	for p := range m.points {
+		if first {
+			r = Rect{p.X, p.Y, p.X + 1, p.Y + 1}
+			first = false
+			continue
+		}
+		if p.X < r.X0 {
+			r.X0 = p.X
+		}
+		if p.Y < r.Y0 {
+			r.Y0 = p.Y
+		}
+		if p.X >= r.X1 {
+			r.X1 = p.X + 1
+		}
+		if p.Y >= r.Y1 {
+			r.Y1 = p.Y + 1
+		}
+	}
+	return r
+}
+
+// Apply applies f for each point in m in the same order as Rect.Apply.
+func (m *Mask) Apply(f func(p XY)) {
+	m.Bounds().Apply(func(p XY) {
+		if m.points[p] {
+			f(p)
+		}
+	})
+}
+
+// Without returns a copy of m with every point of r removed.
+func (m *Mask) Without(r Rect) *Mask {
+	n := &Mask{}
+	m.Apply(func(p XY) {
+		if !p.In(r) {
+			n.Add(p)
+		}
+	})
+	return n
+}
+
+// OddPoint returns a random point from m with odd x and y
+// coordinates relative to the corner of its bounds, like
+// Rect.OddPoint. Reports false if m has no such point.
+func (m *Mask) OddPoint() (XY, bool) {
+	if m.odd == nil {
+		b := m.Bounds()
+		m.odd = []XY{}
+		m.Apply(func(p XY) {
+			if (p.X-b.X0)%2 != 0 && (p.Y-b.Y0)%2 != 0 {
+				m.odd = append(m.odd, p)
+			}
+		})
+	}
+	if len(m.odd) == 0 {
+		return XY{}, false
+	}
+	return m.odd[RNG.Intn(len(m.odd))], true
+}
@@ -0,0 +1,79 @@
+package main
+
+import "testing"
+
+// rectMask returns a mask of the points of r.
+func rectMask(r Rect) *Mask {
+	m := &Mask{}
+	r.Apply(m.Add)
+	return m
+}
+
+func TestCircle(t *testing.T) {
+	for r, want := range []int{1, 5, 13, 29} {
+		if got := Circle(XY{3, -2}, r).Len(); got != want {
+			t.Errorf("len(Circle(r=%d)) = %d, want %d", r, got, want)
+		}
+	}
+	if b := Circle(XY{3, -2}, 2).Bounds(); b != (Rect{1, -4, 6, 1}) {
+		t.Errorf("bounds = %v, want {1 -4 6 1}", b)
+	}
+}
+
+func TestMaskWithout(t *testing.T) {
+	m := rectMask(Rect{0, 0, 4, 4}).Without(Rect{1, 1, 3, 3})
+	if m.Len() != 12 || m.Contains(XY{1, 1}) || !m.Contains(XY{0, 1}) {
+		t.Errorf("mask = %v, want the ring around {1 1 3 3}", m)
+	}
+}
+
+func TestMaskOddPoint(t *testing.T) {
+	// The odd points are relative to the corner, like Rect.OddPoint.
+	r := Rect{2, 2, 7, 7}
+	m := rectMask(r)
+	for i := 0; i < 50; i++ {
+		p, ok := m.OddPoint()
+		q, _ := r.OddPoint()
+		for _, p := range []XY{p, q} {
+			if !ok || (p.X-2)%2 != 1 || (p.Y-2)%2 != 1 || !p.In(r) {
+				t.Fatalf("odd point %v of %v", p, r)
+			}
+		}
+	}
+	thin := rectMask(Rect{0, 0, 1, 5})
+	if _, ok := thin.OddPoint(); ok {
+		t.Errorf("odd point in a mask one wide")
+	}
+	// Adding a point resets the cached odd points.
+	thin.Add(XY{1, 1})
+	if p, ok := thin.OddPoint(); !ok || p != (XY{1, 1}) {
+		t.Errorf("odd point %v, %v after adding {1 1}", p, ok)
+	}
+	if _, ok := (Rect{0, 0, 1, 5}).OddPoint(); ok {
+		t.Errorf("odd point in a rect one wide")
+	}
+	if _, ok := (&Mask{}).OddPoint(); ok {
+		t.Errorf("odd point in an empty mask")
+	}
+}
+
+func TestMazeInCircle(t *testing.T) {
+	c := Circle(XY{}, 8)
+	for _, maze := range []MazeFunc{MazeDFS, MazePrim} {
+		tiles := map[XY]Tile{}
+		maze(tiles, c)
+		if len(tiles) == 0 {
+			t.Errorf("no maze carved")
+		}
+		for p := range tiles {
+			if !c.Contains(p) {
+				t.Errorf("maze carved %v outside the circle", p)
+			}
+		}
+	}
+	tiles := map[XY]Tile{}
+	MazeDFS(tiles, NewMask(XY{}))
+	if len(tiles) != 0 {
+		t.Errorf("maze carved in a mask without odd points")
+	}
+}
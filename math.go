@@ -1,11 +1,9 @@
 package main
 
-import "math/rand"
-
 // randPop removes and returns a random element from the list and
 // the updated list.
 func randPop[T any](slice []T) (T, []T) {
-	i := rand.Intn(len(slice))
+	i := RNG.Intn(len(slice))
 	elem := slice[i]
 	slice[i] = slice[len(slice)-1]
 	slice = slice[:len(slice)-1]
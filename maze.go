@@ -1,22 +1,24 @@
 package main
 
-import "math/rand"
+// MazeFunc generates a maze in the given area.
+type MazeFunc func(map[XY]Tile, Area)
 
-// MazeFunc generates a maze in the given bounds.
-type MazeFunc func(map[XY]Tile, Rect)
-
-// MazeDFS generates a maze in the given bounds.
+// MazeDFS generates a maze in the given area.
 // Implemented using Depth-First Search.
-func MazeDFS(tiles map[XY]Tile, bounds Rect) {
-	p := mazeStartingPoint(tiles, bounds)
+func MazeDFS(tiles map[XY]Tile, bounds Area) {
+	p, ok := mazeStartingPoint(tiles, bounds)
+	if !ok {
+		return
+	}
 	var dfs func(p XY)
 	dfs = func(p XY) {
 		dirs := [...]XY{North, South, West, East}
-		rand.Shuffle(len(dirs), func(i, j int) {
+		RNG.Shuffle(len(dirs), func(i, j int) {
 			dirs[i], dirs[j] = dirs[j], dirs[i]
 		})
 		for _, dir := range dirs {
-			if q := p.Add(dir.Mul(2)); q.In(bounds) && tiles[q] == Wall {
+			q := p.Add(dir.Mul(2))
+			if mazeStep(bounds, p, dir) && tiles[q] == Wall {
 				tiles[p.Add(dir)] = Floor
 				tiles[q] = Floor
 				dfs(q)
@@ -26,10 +28,14 @@ func MazeDFS(tiles map[XY]Tile, bounds Rect) {
 	dfs(p)
 }
 
-// MazePrim generates a maze in the given bounds.
+// MazePrim generates a maze in the given area.
 // Implemented using Prim's algorithm.
-func MazePrim(tiles map[XY]Tile, bounds Rect) {
-	check := []XY{mazeStartingPoint(tiles, bounds)}
+func MazePrim(tiles map[XY]Tile, bounds Area) {
+	p, ok := mazeStartingPoint(tiles, bounds)
+	if !ok {
+		return
+	}
+	check := []XY{p}
 	for len(check) > 0 {
 		var xy XY
 		xy, check = randPop(check)
@@ -39,36 +45,45 @@ func MazePrim(tiles map[XY]Tile, bounds Rect) {
 		tiles[xy] = Floor
 
 		dirs := []XY{North, South, West, East}
-		rand.Shuffle(len(dirs), func(i, j int) {
+		RNG.Shuffle(len(dirs), func(i, j int) {
 			dirs[i], dirs[j] = dirs[j], dirs[i]
 		})
 		for _, dir := range dirs {
 			p := xy.Add(dir.Mul(2))
-			if p.In(bounds) && tiles[p] == Floor {
+			if mazeStep(bounds, xy, dir) && tiles[p] == Floor {
 				tiles[xy.Add(dir)] = Floor
 				break
 			}
 		}
 		for _, dir := range dirs {
 			p := xy.Add(dir.Mul(2))
-			if p.In(bounds) && tiles[p] == Wall {
+			if mazeStep(bounds, xy, dir) && tiles[p] == Wall {
 				check = append(check, p)
 			}
 		}
 	}
 }
 
-// mazeStartingPoint returns an odd point in the given bounds which
-// has a Wall tile.
-func mazeStartingPoint(tiles map[XY]Tile, bounds Rect) XY {
+// mazeStep reports whether a maze can be carved from p two steps in
+// the direction dir without leaving the given area.
+func mazeStep(bounds Area, p, dir XY) bool {
+	return bounds.Contains(p.Add(dir)) && bounds.Contains(p.Add(dir.Mul(2)))
+}
+
+// mazeStartingPoint returns an odd point in the given area which
+// has a Wall tile. Reports false if none is found.
+func mazeStartingPoint(tiles map[XY]Tile, bounds Area) (XY, bool) {
 	const max = 1000
 	for i := 0; i < max; i++ {
-		p := bounds.OddPoint()
+		p, ok := bounds.OddPoint()
+		if !ok {
+			break
+		}
 		if tiles[p] == Wall {
-			return p
+			return p, true
 		}
 	}
-	panic("cannot get maze starting point")
+	return XY{}, false
 }
 
 // removeDeadEnds removes the tiles that have only one floor neighbor.
@@ -94,17 +109,18 @@ func removeDeadEnds(tiles map[XY]Tile) int {
 	return r
 }
 
-// growMap grows the map using cellular automata.
-func growMap(tiles map[XY]Tile) {
+// growMap grows the map in the given area using cellular automata.
+func growMap(tiles map[XY]Tile, bounds Area) {
 	walls := map[XY]bool{}
 	for floor := range tiles {
 		for _, neighbor := range floor.Neighbors() {
-			if tiles[neighbor] == Wall {
+			if tiles[neighbor] == Wall && bounds.Contains(neighbor) {
 				walls[neighbor] = true
 			}
 		}
 	}
-	for wall := range walls {
+	// Grow in a fixed order since every new floor affects the next.
+	for _, wall := range sortedPoints(walls) {
 		n := 0
 		for _, neighbor := range wall.Neighbors() {
 			if tiles[neighbor] == Floor {
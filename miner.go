@@ -1,56 +1,83 @@
 package main
 
-import (
-	"image/color"
-	"math/rand"
-)
+import "image/color"
 
 type Miner struct {
 	XY
 	Bounds Rect
 	Energy int
-	State  *State
+	State  *State `json:"-"`
+	Stats
 }
 
+// minerStats are the combat statistics of a new miner.
+var minerStats = Stats{HP: 6, MaxHP: 6, Attack: 3, Defense: 1}
+
 func (m *Miner) Update() {
-	// Do nothing if dead.
+	// Leave the world if dead.
 	if m.Energy <= 0 {
+		if id, ok := m.State.ID(m); ok {
+			m.State.Remove(id)
+		}
 		return
 	}
 	m.Energy--
 
 	// Move.
-	p := m.XY.Add([]XY{{}, North, South, West, East}[rand.Intn(5)])
+	p := m.XY.Add([]XY{{}, North, South, West, East}[RNG.Intn(5)])
 	if !p.In(m.Bounds) {
 		// Recurse if out of bounds.
 		m.Update()
 		return
 	}
-	m.XY = p
+	// The move fails if a blocker is in the way.
+	m.State.Move(m, p)
 
 	// Dig.
 	if m.State.Tiles[m.XY] == Wall {
 		m.State.Tiles[m.XY] = Floor
-		if rand.Float64() < 0.3 {
-			m.State.Add(&Stone{m.XY})
+		m.State.Events.Publish(Dug{m, m.XY, Wall})
+		if RNG.Float64() < 0.3 {
+			m.State.Drop(NewItem(Items["stone"], m.XY, 1), m.XY)
 		}
 	}
 
-	// Spawn another miner.
-	if rand.Float64() < 0.1 {
-		m.State.Add(&Miner{p, m.Bounds, m.Energy, m.State})
+	// Spawn another miner on a free neighbor point.
+	if RNG.Float64() < 0.1 {
+		for _, q := range m.XY.Orthogonal() {
+			if _, ok := m.State.BlockerAt(q); !ok && q.In(m.Bounds) {
+				m.State.Add(&Miner{q, m.Bounds, m.Energy, m.State, minerStats})
+				break
+			}
+		}
 	}
 
 	// Die if surrounded by empty space.
 	empty := 0
-	for _, neigh := range p.Neighbors() {
+	for _, neigh := range m.XY.Neighbors() {
 		if m.State.Tiles[neigh] == Floor {
 			empty++
 		}
 	}
 	if empty == 8 {
 		m.Energy = 0
+		if id, ok := m.State.ID(m); ok {
+			m.State.Events.Publish(Died{id, m})
+		}
+	}
+}
+
+// Name implements the Namer interface.
+func (m *Miner) Name() string {
+	return "miner"
+}
+
+// Light implements the Emitter interface.
+func (m *Miner) Light() Light {
+	if m.Energy <= 0 {
+		return Light{}
 	}
+	return Light{color.RGBA{0xff, 0x90, 0x40, 0xff}, 4, 1}
 }
 
 func (m *Miner) Symbol() Symbol {
@@ -60,12 +87,12 @@ func (m *Miner) Symbol() Symbol {
 	return Symbol{color.RGBA{0xef, 0xac, 0x28, 0xff}, 'Ḳ'}
 }
 
-type Stone struct {
-	XY
+// XP implements the Rewarder interface.
+func (m *Miner) XP() int {
+	return 1
 }
 
-func (s *Stone) Update() {}
-
-func (s *Stone) Symbol() Symbol {
-	return Symbol{color.RGBA{0x45, 0x23, 0x0d, 0xff}, '●'}
+// Blocks implements the Blocker interface.
+func (m *Miner) Blocks() bool {
+	return true
 }
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"io"
+)
+
+// Kind describes a kind of monsters.
+type Kind struct {
+	Name   string
+	Symbol Symbol
+	Stats  Stats
+	Speed  int
+	Vision Vision
+	// Sight is the FOV algorithm the monster sees with. Shadowcasting
+	// if nil.
+	Sight FOVAlgorithm
+	// Behaviors are tried in order each turn until one of them acts.
+	Behaviors []Behavior
+	// Inflicts are the effects applied to the target on hit.
+	Inflicts []Effect
+	// Loot is dropped on death.
+	Loot []Loot
+	// OpensDoors lets the monster open closed doors which are not
+	// locked.
+	OpensDoors bool
+	// XP is the experience granted for slaying the monster.
+	XP int
+}
+
+//go:embed monsters.json
+var monstersData []byte
+
+func init() {
+	if err := RegisterKinds(bytes.NewReader(monstersData)); err != nil {
+		panic(err)
+	}
+}
+
+// Kinds of monsters by name.
+var Kinds = map[string]*Kind{}
+
+// RegisterKinds reads a JSON list of monster kinds from r and
+// registers them. A kind with the name of an already registered kind
+// replaces it.
+func RegisterKinds(r io.Reader) error {
+	var kinds []*Kind
+	if err := json.NewDecoder(r).Decode(&kinds); err != nil {
+		return fmt.Errorf("monsters: %w", err)
+	}
+	for _, k := range kinds {
+		if k.Name == "" {
+			return fmt.Errorf("monsters: kind without a name")
+		}
+		if old, ok := Kinds[k.Name]; ok {
+			*old = *k
+		} else {
+			Kinds[k.Name] = k
+		}
+	}
+	return nil
+}
+
+func (k *Kind) UnmarshalJSON(data []byte) error {
+	var def struct {
+		Name      string
+		Glyph     glyph
+		Color     hexColor
+		HP        int
+		Attack    int
+		Defense   int
+		Speed     int
+		Vision    int
+		Sight     string
+		Behaviors []behavior
+		Inflicts  []Effect
+		Loot      []Loot
+		Doors     bool
+		XP        int
+	}
+	if err := json.Unmarshal(data, &def); err != nil {
+		return err
+	}
+	*k = Kind{
+		Name:     def.Name,
+		Symbol:   Symbol{color.RGBA(def.Color), rune(def.Glyph)},
+		Stats:    Stats{def.HP, def.HP, def.Attack, def.Defense},
+		Speed:    def.Speed,
+		Vision:   Vision{Radius: def.Vision},
+		Inflicts: def.Inflicts,
+		Loot:     def.Loot,
+
+		OpensDoors: def.Doors,
+		XP:         def.XP,
+	}
+	if k.Speed == 0 {
+		k.Speed = NormalSpeed
+	}
+	if def.Sight != "" {
+		if k.Sight = sights[def.Sight]; k.Sight == nil {
+			return fmt.Errorf("%s: unknown sight %q", k.Name, def.Sight)
+		}
+	}
+	for _, b := range def.Behaviors {
+		k.Behaviors = append(k.Behaviors, b.Behavior)
+	}
+	for _, l := range k.Loot {
+		if Items[l.Item] == nil {
+			return fmt.Errorf("%s: unknown loot %q", k.Name, l.Item)
+		}
+	}
+	return nil
+}
+
+// XP implements the Rewarder interface.
+func (m *Monster) XP() int {
+	return m.Kind.XP
+}
+
+// Loot is an item dropped by a dying monster with a chance. Count is
+// the maximum size of the dropped stack.
+type Loot struct {
+	Item   string
+	Chance float64
+	Count  int
+}
+
+// Monster is an entity driven by the behaviors of its kind.
+type Monster struct {
+	XY
+	Stats
+	Kind  *Kind
+	Mind  Mind
+	State *State `json:"-"`
+}
+
+// NewMonster returns a new monster of the given kind at p.
+func NewMonster(k *Kind, p XY, s *State) *Monster {
+	return &Monster{
+		XY:    p,
+		Stats: k.Stats,
+		Kind:  k,
+		Mind:  Mind{Home: p},
+		State: s,
+	}
+}
+
+// Update perceives the surroundings, changes the mode of the mind and
+// acts on the first behavior which decides to. A confused monster
+// wanders instead.
+func (m *Monster) Update() {
+	m.perceive()
+	m.think()
+	if m.State.HasEffect(m, Confused) && RNG.Float64() < 0.5 {
+		Wander{}.Act(m)
+		return
+	}
+	for _, b := range m.Kind.Behaviors {
+		if b.Act(m) {
+			return
+		}
+	}
+}
+
+// Name implements the Namer interface.
+func (m *Monster) Name() string {
+	return m.Kind.Name
+}
+
+// Speed implements the Speeder interface.
+func (m *Monster) Speed() int {
+	return m.attributes()[SpeedStat]
+}
+
+// Inflicts implements the Inflicter interface.
+func (m *Monster) Inflicts() []Effect {
+	return m.Kind.Inflicts
+}
+
+// attributes returns the derived statistics of the monster modified
+// by its status effects.
+func (m *Monster) attributes() Attributes {
+	a := Attributes{
+		AttackStat:  m.Attack,
+		DefenseStat: m.Defense,
+		SightStat:   m.Kind.Vision.Radius,
+		SpeedStat:   m.Kind.Speed,
+	}
+	return a.Apply(m.State.EffectModifiers(m))
+}
+
+// Loot implements the Looter interface.
+func (m *Monster) Loot() []*Item {
+	items := []*Item{}
+	for _, l := range m.Kind.Loot {
+		if RNG.Float64() >= l.Chance {
+			continue
+		}
+		n := 1
+		if l.Count > 1 {
+			n += RNG.Intn(l.Count)
+		}
+		items = append(items, NewItem(Items[l.Item], m.XY, n))
+	}
+	return items
+}
+
+func (m *Monster) Symbol() Symbol {
+	return m.Kind.Symbol
+}
+
+// Blocks implements the Blocker interface.
+func (m *Monster) Blocks() bool {
+	return true
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"container/heap"
+	"math"
+)
+
+// PathOptions configures path finding.
+type PathOptions struct {
+	// Diagonal allows 8-directional movement.
+	Diagonal bool
+	// CutCorners allows diagonal moves past impassable orthogonal
+	// neighbors.
+	CutCorners bool
+	// AvoidEntities treats points occupied by entities which block
+	// movement as impassable, except for the goal.
+	AvoidEntities bool
+	// OpenDoors treats closed doors which are not locked as passable.
+	OpenDoors bool
+}
+
+// walkable reports whether the tile can be walked on with the options.
+func walkable(t Tile, opt PathOptions) bool {
+	if opt.OpenDoors && t.Closed() && !t.Locked() {
+		return true
+	}
+	return t.Passable()
+}
+
+// Path returns the cheapest path from p to q over the state tiles
+// excluding p and including q. Reports false if there is no path.
+func (s *State) Path(p, q XY, opt PathOptions) ([]XY, bool) {
+	blocked := func(XY) bool { return false }
+	if opt.AvoidEntities {
+		blocked = func(x XY) bool {
+			_, ok := s.BlockerAt(x)
+			return ok && x != q
+		}
+	}
+	return FindPath(s.Tiles, p, q, opt, blocked)
+}
+
+// FindPath returns the cheapest path from p to q over the tiles
+// excluding p and including q. blocked returns true if its argument
+// is impassable regardless of the tile. Reports false if there is no
+// path. Implemented using A*.
+func FindPath(tiles map[XY]Tile, p, q XY, opt PathOptions, blocked func(XY) bool) ([]XY, bool) {
+	passable := func(x XY) bool {
+		return walkable(tiles[x], opt) && !blocked(x)
+	}
+	dirs := []XY{North, South, West, East}
+	if opt.Diagonal {
+		dirs = append(dirs, North.Add(West), North.Add(East), South.Add(West), South.Add(East))
+	}
+
+	from := map[XY]XY{}
+	cost := map[XY]float64{p: 0}
+	open := &pathQueue{{p, heuristic(p, q, opt.Diagonal)}}
+	for open.Len() > 0 {
+		x := heap.Pop(open).(pathNode).XY
+		if x == q {
+			path := []XY{}
+			for ; x != p; x = from[x] {
+				path = append(path, x)
+			}
+			for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+				path[i], path[j] = path[j], path[i]
+			}
+			return path, true
+		}
+		for _, dir := range dirs {
+			y := x.Add(dir)
+			if !passable(y) {
+				continue
+			}
+			step := 1.0
+			if dir.X != 0 && dir.Y != 0 {
+				if !opt.CutCorners && (!passable(x.Add(XY{X: dir.X})) || !passable(x.Add(XY{Y: dir.Y}))) {
+					continue
+				}
+				step = math.Sqrt2
+			}
+			c := cost[x] + step*float64(tileCost(tiles[y]))
+			if old, ok := cost[y]; ok && old <= c {
+				continue
+			}
+			cost[y] = c
+			from[y] = x
+			heap.Push(open, pathNode{y, c + heuristic(y, q, opt.Diagonal)})
+		}
+	}
+	return nil, false
+}
+
+// tileCost returns the cost of moving onto the tile, at least 1.
+func tileCost(t Tile) int {
+	if c := t.Cost(); c > 1 {
+		return c
+	}
+	return 1
+}
+
+// heuristic returns the lowest possible cost of moving from p to q.
+func heuristic(p, q XY, diagonal bool) float64 {
+	dx, dy := float64(abs(p.X-q.X)), float64(abs(p.Y-q.Y))
+	if !diagonal {
+		return dx + dy
+	}
+	return dx + dy + (math.Sqrt2-2)*math.Min(dx, dy)
+}
+
+type pathNode struct {
+	XY
+	priority float64
+}
+
+// pathQueue is a min-heap of path nodes ordered by priority.
+type pathQueue []pathNode
+
+func (q pathQueue) Len() int           { return len(q) }
+func (q pathQueue) Less(i, j int) bool { return q[i].priority < q[j].priority }
+func (q pathQueue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+func (q *pathQueue) Push(x any)        { *q = append(*q, x.(pathNode)) }
+
+func (q *pathQueue) Pop() any {
+	old := *q
+	x := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return x
+}
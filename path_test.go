@@ -0,0 +1,69 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestFindPath(t *testing.T) {
+	// ....
+	// .#..
+	// ..#.
+	tiles := map[XY]Tile{}
+	Rect{0, 0, 4, 3}.Apply(func(p XY) { tiles[p] = Floor })
+	tiles[XY{1, 1}] = Wall
+	tiles[XY{2, 2}] = Wall
+	none := func(XY) bool { return false }
+
+	tests := []struct {
+		opt  PathOptions
+		want int
+	}{
+		{PathOptions{}, 7},
+		{PathOptions{Diagonal: true}, 6},
+		{PathOptions{Diagonal: true, CutCorners: true}, 3},
+	}
+	for _, tt := range tests {
+		path, ok := FindPath(tiles, XY{0, 2}, XY{3, 2}, tt.opt, none)
+		if !ok || len(path) != tt.want {
+			t.Errorf("FindPath(%+v) = %v, %v, want %d steps", tt.opt, path, ok, tt.want)
+			continue
+		}
+		if path[len(path)-1] != (XY{3, 2}) {
+			t.Errorf("FindPath(%+v) ends at %v", tt.opt, path[len(path)-1])
+		}
+	}
+
+	if _, ok := FindPath(tiles, XY{0, 2}, XY{3, 2}, PathOptions{}, func(p XY) bool {
+		return p.X == 2
+	}); ok {
+		t.Errorf("found path through blocked points")
+	}
+}
+
+func benchmarkFindPath(b *testing.B, size int) {
+	tiles := map[XY]Tile{}
+	Cave{MazePrim, 7, 3, 3}.Generate(tiles, Rect{0, 0, size, size})
+	floors := []XY{}
+	for p, t := range tiles {
+		if t == Floor {
+			floors = append(floors, p)
+		}
+	}
+	rng := rand.New(rand.NewSource(1))
+	none := func(XY) bool { return false }
+	opt := PathOptions{Diagonal: true}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		p, q := floors[rng.Intn(len(floors))], floors[rng.Intn(len(floors))]
+		FindPath(tiles, p, q, opt, none)
+	}
+}
+
+func BenchmarkFindPath81(b *testing.B) {
+	benchmarkFindPath(b, 81)
+}
+
+func BenchmarkFindPath500(b *testing.B) {
+	benchmarkFindPath(b, 500)
+}
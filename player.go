@@ -8,22 +8,65 @@ import (
 
 type Player struct {
 	XY
+	Stats
 	Explored map[XY]bool
-	FOV      map[XY]bool
-	Radius   int
-	Updated  bool
-	State    *State
+	FOV      map[XY]bool  `json:"-"`
+	Sight    FOVAlgorithm `json:"-"`
+	Vision   Vision
+	State    *State `json:"-"`
+
+	Inventory Inventory
+	Equipment [numSlots]*Item
+
+	// Base and Effective are the derived statistics before and after
+	// applying the modifiers of the equipment.
+	Base      Attributes
+	Effective Attributes
+
+	// Kills is the number of monsters slain by the player and
+	// KilledBy is the name of the monster that killed the player.
+	Kills    int
+	KilledBy string
+
+	// Digging is the point the player is digging into and DigEffort
+	// is the effort spent on it so far.
+	Digging   XY
+	DigEffort int
+
+	// Level and XP are the experience of the player. Discovered is the
+	// number of tiles explored on all levels. Perks are the names of
+	// the chosen perks and PerkPoints the number of perks to choose.
+	Level      int
+	XP         int
+	Discovered int
+	Perks      []string
+	PerkPoints int
 }
 
 func NewPlayer(pos XY, radius int, s *State) *Player {
 	p := &Player{
 		XY:       pos,
+		Stats:    Stats{HP: 20, MaxHP: 20},
 		Explored: map[XY]bool{},
 		FOV:      map[XY]bool{},
-		Radius:   radius,
-		Updated:  true,
-		State:    s,
+		Sight:    Shadowcasting{},
+		Vision:   Vision{Radius: radius},
+		Base: Attributes{
+			AttackStat:     5,
+			DefenseStat:    2,
+			SightStat:      radius,
+			LightStat:      3,
+			SpeedStat:      NormalSpeed,
+			PerceptionStat: 3,
+		},
+		State: s,
+		Level: 1,
+		Inventory: Inventory{
+			Slots:     10,
+			MaxWeight: 50,
+		},
 	}
+	p.Recompute()
 	p.UpdateFOV()
 	return p
 }
@@ -32,47 +75,92 @@ func (p *Player) Symbol() Symbol {
 	return Symbol{color.RGBA{0xef, 0xac, 0x28, 0xff}, '☺'}
 }
 
+// Name implements the Namer interface.
+func (p *Player) Name() string {
+	return "player"
+}
+
+// Light implements the Emitter interface.
+func (p *Player) Light() Light {
+	return Light{color.RGBA{0xff, 0xd8, 0xa0, 0xff}, p.Effective[LightStat], 1}
+}
+
+// Speed implements the Speeder interface.
+func (p *Player) Speed() int {
+	return p.Effective[SpeedStat]
+}
+
+// UpdateFOV updates the lit points in the Field of View of the player
+// and explores them. The omniscient debug view explores nothing.
 func (p *Player) UpdateFOV() {
-	p.FOV = p.XY.FOV(p.Radius, func(xy XY) bool { return p.State.Tiles[xy].Opaque() })
+	fov := p.Sight.FOV(p.XY, p.Vision, func(xy XY) bool { return p.State.Tiles[xy].Opaque() })
+	_, omniscient := p.Sight.(Omniscient)
+	p.FOV = map[XY]bool{}
+	for xy := range fov {
+		if omniscient || p.State.Light[xy].Lit() {
+			p.FOV[xy] = true
+		}
+	}
+	if omniscient {
+		return
+	}
+	discovered := 0
 	for xy := range p.FOV {
-		p.Explored[xy] = true
+		if !p.Explored[xy] {
+			p.Explored[xy] = true
+			discovered++
+		}
 	}
+	p.discover(discovered)
 }
 
-func (p *Player) Update() {
-	if p.Updated {
-		return
+// Update implements the Entity interface. The player acts on the
+// commands given to Game.Act instead.
+func (p *Player) Update() {}
+
+// input returns the command given by the pressed keys. A direction
+// with Shift digs.
+func input() (Command, bool) {
+	c, ok := keyCommand()
+	if ok && c.Action == MoveAction && c.Dir != (XY{}) && pressed(ebiten.KeyShift) {
+		c.Action = DigAction
 	}
-	p.Updated = true
+	return c, ok
+}
 
-	var offset XY
+// keyCommand returns the command bound to the pressed keys.
+func keyCommand() (Command, bool) {
 	switch {
 	case pressed(ebiten.KeyUp, ebiten.KeyNumpad8, ebiten.KeyW):
-		offset = North
+		return Command{Action: MoveAction, Dir: North}, true
 	case pressed(ebiten.KeyDown, ebiten.KeyNumpad2, ebiten.KeyX):
-		offset = South
+		return Command{Action: MoveAction, Dir: South}, true
 	case pressed(ebiten.KeyLeft, ebiten.KeyNumpad4, ebiten.KeyA):
-		offset = West
+		return Command{Action: MoveAction, Dir: West}, true
 	case pressed(ebiten.KeyRight, ebiten.KeyNumpad6, ebiten.KeyD):
-		offset = East
+		return Command{Action: MoveAction, Dir: East}, true
 	case pressed(ebiten.KeyNumpad7, ebiten.KeyQ):
-		offset = North.Add(West)
+		return Command{Action: MoveAction, Dir: North.Add(West)}, true
 	case pressed(ebiten.KeyNumpad9, ebiten.KeyE):
-		offset = North.Add(East)
+		return Command{Action: MoveAction, Dir: North.Add(East)}, true
 	case pressed(ebiten.KeyNumpad1, ebiten.KeyZ):
-		offset = South.Add(West)
+		return Command{Action: MoveAction, Dir: South.Add(West)}, true
 	case pressed(ebiten.KeyNumpad3, ebiten.KeyC):
-		offset = South.Add(East)
+		return Command{Action: MoveAction, Dir: South.Add(East)}, true
 	case pressed(ebiten.KeyNumpad5, ebiten.KeyS):
-	default:
-		p.Updated = false
-	}
-	if p.Updated {
-		if q := p.XY.Add(offset); p.State.Tiles[q].Passable() {
-			p.XY = q
-		}
-		p.UpdateFOV()
+		return Command{Action: MoveAction}, true
+	case pressed(ebiten.KeyG, ebiten.KeyComma):
+		return Command{Action: PickUpAction}, true
+	case justPressed(ebiten.KeyK):
+		return Command{Action: CloseAction}, true
+	case justPressed(ebiten.KeyB):
+		return Command{Action: BashAction}, true
+	case justPressed(ebiten.KeyF):
+		return Command{Action: SearchAction}, true
+	case justPressed(ebiten.KeyT):
+		return Command{Action: DisarmAction}, true
 	}
+	return Command{}, false
 }
 
 func pressed(ks ...ebiten.Key) bool {
@@ -83,3 +171,8 @@ func pressed(ks ...ebiten.Key) bool {
 	}
 	return false
 }
+
+// Blocks implements the Blocker interface.
+func (p *Player) Blocks() bool {
+	return true
+}
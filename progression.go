@@ -0,0 +1,206 @@
+package main
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+//go:embed progression.json
+var progressionData []byte
+
+func init() {
+	p, err := ReadProgression(bytes.NewReader(progressionData))
+	if err != nil {
+		panic(err)
+	}
+	Progress = p
+}
+
+// Progress is the progression curve of the game.
+var Progress *Progression
+
+// Progression defines the experience needed for the levels of the
+// player and the rewards of a new level.
+type Progression struct {
+	// Levels are the total experience needed for the levels from the
+	// second on.
+	Levels []int
+	// ExploreTiles is the number of newly explored tiles worth one
+	// point of experience.
+	ExploreTiles int
+	// HP and Stats are gained on every new level along with a perk.
+	HP    int
+	Stats []Modifier
+	Perks []Perk
+}
+
+// Perk is a permanent bonus chosen by the player on a new level.
+type Perk struct {
+	Name        string
+	Description string
+	Modifiers   []Modifier
+}
+
+// ReadProgression reads a JSON progression from r.
+func ReadProgression(r io.Reader) (*Progression, error) {
+	p := &Progression{}
+	if err := json.NewDecoder(r).Decode(p); err != nil {
+		return nil, fmt.Errorf("progression: %w", err)
+	}
+	if p.ExploreTiles <= 0 {
+		return nil, fmt.Errorf("progression: exploreTiles must be positive")
+	}
+	for i := 1; i < len(p.Levels); i++ {
+		if p.Levels[i] <= p.Levels[i-1] {
+			return nil, fmt.Errorf("progression: levels must be increasing")
+		}
+	}
+	return p, nil
+}
+
+// Perk returns the perk with the given name.
+func (pr *Progression) Perk(name string) (Perk, bool) {
+	for _, perk := range pr.Perks {
+		if perk.Name == name {
+			return perk, true
+		}
+	}
+	return Perk{}, false
+}
+
+// Next returns the total experience needed for the level after the
+// given one. Reports false if it is the last level.
+func (pr *Progression) Next(level int) (int, bool) {
+	if level < 1 || level > len(pr.Levels) {
+		return 0, false
+	}
+	return pr.Levels[level-1], true
+}
+
+// Rewarder is implemented by any entity that grants experience when
+// slain by the player.
+type Rewarder interface {
+	XP() int
+}
+
+// GainXP adds the experience to the player and raises the level once
+// for every threshold reached.
+func (p *Player) GainXP(n int) {
+	p.XP += n
+	for {
+		next, ok := Progress.Next(p.Level)
+		if !ok || p.XP < next {
+			return
+		}
+		p.Level++
+		p.MaxHP += Progress.HP
+		p.HP += Progress.HP
+		p.Base = p.Base.Apply(Progress.Stats)
+		if len(p.AvailablePerks()) > p.PerkPoints {
+			p.PerkPoints++
+		}
+		p.Recompute()
+		p.State.Events.Publish(LeveledUp{p, p.Level})
+	}
+}
+
+// discover grants the experience for the number of newly explored
+// tiles.
+func (p *Player) discover(n int) {
+	before := p.Discovered / Progress.ExploreTiles
+	p.Discovered += n
+	if xp := p.Discovered/Progress.ExploreTiles - before; xp > 0 {
+		p.GainXP(xp)
+	}
+}
+
+// AvailablePerks returns the perks the player has not chosen yet.
+func (p *Player) AvailablePerks() []Perk {
+	perks := []Perk{}
+	for _, perk := range Progress.Perks {
+		if !in(p.Perks, perk.Name) {
+			perks = append(perks, perk)
+		}
+	}
+	return perks
+}
+
+// ChoosePerk spends a perk point on the available perk with the given
+// index. Reports whether the perk was chosen.
+func (p *Player) ChoosePerk(i int) bool {
+	perks := p.AvailablePerks()
+	if p.PerkPoints <= 0 || i < 0 || i >= len(perks) {
+		return false
+	}
+	p.Perks = append(p.Perks, perks[i].Name)
+	p.PerkPoints--
+	p.Recompute()
+	return true
+}
+
+// LeveledUp is published when an entity reaches a new level.
+type LeveledUp struct {
+	Entity Entity
+	Level  int
+}
+
+func (e LeveledUp) At() XY { return e.Entity.Pos() }
+
+// PerkScreen lets the player choose a perk.
+type PerkScreen struct {
+	Selected int
+}
+
+func (s *PerkScreen) Update(g *Game) bool {
+	perks := g.Player.AvailablePerks()
+	switch {
+	case justPressed(ebiten.KeyEscape, ebiten.KeyP):
+		return false
+	case justPressed(ebiten.KeyUp, ebiten.KeyNumpad8):
+		s.Selected--
+	case justPressed(ebiten.KeyDown, ebiten.KeyNumpad2):
+		s.Selected++
+	case justPressed(ebiten.KeyEnter) && len(perks) > 0:
+		g.Act(Command{Action: PerkAction, Item: s.Selected})
+		if g.Player.PerkPoints == 0 {
+			return false
+		}
+	}
+	s.clamp(len(g.Player.AvailablePerks()))
+	return true
+}
+
+// clamp keeps the selection within the n listed perks.
+func (s *PerkScreen) clamp(n int) {
+	if s.Selected >= n {
+		s.Selected = n - 1
+	}
+	if s.Selected < 0 {
+		s.Selected = 0
+	}
+}
+
+func (s *PerkScreen) Draw(g *Game) {
+	p := g.Player
+	g.Terminal.Text(XY{1, 0}, "Perks (Enter to choose, Esc to close)", colorNotice)
+	g.Terminal.Text(XY{1, 1}, fmt.Sprintf("Level %d, %d to choose", p.Level, p.PerkPoints), colorInfo)
+	perks := p.AvailablePerks()
+	if len(perks) == 0 {
+		g.Terminal.Text(XY{1, 3}, "You have every perk.", colorInfo)
+		return
+	}
+	s.clamp(len(perks))
+	for i, perk := range perks {
+		c := colorInfo
+		if i == s.Selected {
+			c = colorNotice
+		}
+		g.Terminal.Text(XY{1, 3 + i}, fmt.Sprintf("%c) %s", 'a'+i, perk.Name), c)
+	}
+	g.Terminal.Text(XY{1, 4 + len(perks)}, perks[s.Selected].Description, colorInfo)
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGainXP(t *testing.T) {
+	_, p := arena(3, XY{1, 1})
+	hp, attack := p.MaxHP, p.Attack
+	p.GainXP(Progress.Levels[1])
+	if p.Level != 3 || p.PerkPoints != 2 {
+		t.Errorf("level %d, perk points %d, want 3 and 2", p.Level, p.PerkPoints)
+	}
+	if p.MaxHP != hp+2*Progress.HP || p.Attack <= attack {
+		t.Errorf("max HP %d, attack %d, want raised from %d and %d", p.MaxHP, p.Attack, hp, attack)
+	}
+
+	sight := p.Vision.Radius
+	if !p.ChoosePerk(0) || p.Perks[0] != "keen eyes" || p.Vision.Radius != sight+3 {
+		t.Errorf("perks %v, sight %d, want keen eyes and %d", p.Perks, p.Vision.Radius, sight+3)
+	}
+	if len(p.AvailablePerks()) != len(Progress.Perks)-1 {
+		t.Errorf("a chosen perk is still available")
+	}
+	p.ChoosePerk(0)
+	if p.ChoosePerk(0) {
+		t.Errorf("chose a perk without points")
+	}
+}
+
+func TestXPSources(t *testing.T) {
+	s, p := arena(10, XY{1, 1})
+	p.discover(Progress.ExploreTiles*2 - 1)
+	if p.XP != 1 {
+		t.Errorf("XP = %d after exploring, want 1", p.XP)
+	}
+	m := NewMonster(Kinds["rat"], XY{2, 1}, s)
+	s.Add(m)
+	m.HP = 1
+	p.Attack = 100
+	for i := 0; i < 100 && m.HP > 0; i++ {
+		p.Do(Command{Action: MoveAction, Dir: East})
+	}
+	if want := 1 + Kinds["rat"].XP; p.XP != want {
+		t.Errorf("XP = %d after the kill, want %d", p.XP, want)
+	}
+}
+
+func TestStealth(t *testing.T) {
+	s, p := arena(10, XY{1, 1})
+	s.UpdateLight()
+	m := NewMonster(Kinds["goblin"], XY{6, 1}, s)
+	s.Add(m)
+	p.Base[StealthStat] = 100
+	p.Recompute()
+	if m.perceive(); m.Mind.Target != nil {
+		t.Errorf("goblin noticed a stealthy player")
+	}
+	s.Move(m, XY{2, 2})
+	if m.perceive(); m.Mind.Target == nil {
+		t.Errorf("goblin did not notice an adjacent player")
+	}
+}
+
+func TestReadProgression(t *testing.T) {
+	for _, data := range []string{
+		`{"levels": [10, 5], "exploreTiles": 1}`,
+		`{"levels": [10], "exploreTiles": 0}`,
+		`{"levels": [10], "exploreTiles": 1, "stats": [{"stat": "Luck", "add": 1}]}`,
+	} {
+		if _, err := ReadProgression(strings.NewReader(data)); err == nil {
+			t.Errorf("read invalid progression %s", data)
+		}
+	}
+}
+
+func TestRevealExploresNothing(t *testing.T) {
+	g := NewGame(1)
+	explored, xp := len(g.Player.Explored), g.Player.XP
+	g.Reveal()
+	if len(g.Player.Explored) != explored || g.Player.XP != xp {
+		t.Errorf("reveal explored %d tiles and granted %d XP", len(g.Player.Explored)-explored, g.Player.XP-xp)
+	}
+	if len(g.Replay.Commands) != 0 {
+		t.Errorf("the reveal was recorded")
+	}
+	g.Reveal()
+	if len(g.Player.Explored) != explored || g.Player.XP != xp {
+		t.Errorf("explored %d, XP %d after the reveal, want %d and %d", len(g.Player.Explored), g.Player.XP, explored, xp)
+	}
+}
+
+func TestPerkScreenSelection(t *testing.T) {
+	g := NewGame(1)
+	g.Player.GainXP(Progress.Levels[2])
+	last := len(g.Player.AvailablePerks()) - 1
+	s := &PerkScreen{Selected: last}
+	g.Act(Command{Action: PerkAction, Item: last})
+	if !s.Update(g) {
+		t.Fatalf("the screen closed with perk points left")
+	}
+	if n := len(g.Player.AvailablePerks()); s.Selected >= n {
+		t.Errorf("Selected = %d with %d perks", s.Selected, n)
+	}
+}
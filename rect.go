@@ -1,7 +1,5 @@
 package main
 
-import "math/rand"
-
 // Rect represents a rectangle.
 type Rect struct {
 	X0, Y0, X1, Y1 int
@@ -23,6 +21,11 @@ func (r Rect) In(s Rect) bool {
 		r.Y0 >= s.Y0 && r.Y1 <= s.Y1
 }
 
+// Contains reports whether p is in r.
+func (r Rect) Contains(p XY) bool {
+	return p.In(r)
+}
+
 func (r Rect) Inset(n int) Rect {
 	return Rect{r.X0 + n, r.Y0 + n, r.X1 - n, r.Y1 - n}
 }
@@ -37,10 +40,14 @@ func (r Rect) Apply(f func(p XY)) {
 }
 
 // OddPoint returns a random point from r with odd x and y
-// coordinates.
-func (r Rect) OddPoint() XY {
-	return XY{
-		r.X0 + rand.Intn(r.Dx()/2)*2 + 1,
-		r.Y0 + rand.Intn(r.Dy()/2)*2 + 1,
+// coordinates relative to the corner of r. Reports false if r is too
+// small to have one.
+func (r Rect) OddPoint() (XY, bool) {
+	if r.Dx() < 2 || r.Dy() < 2 {
+		return XY{}, false
 	}
+	return XY{
+		r.X0 + RNG.Intn(r.Dx()/2)*2 + 1,
+		r.Y0 + RNG.Intn(r.Dy()/2)*2 + 1,
+	}, true
 }
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// replayVersion is the version of the replay format.
+const replayVersion = 1
+
+// replayPath is the file the replay is written to on quit.
+const replayPath = "cave.replay"
+
+// Replay is the seed of a game and the commands of the player. Hash is
+// the hash of the final state.
+type Replay struct {
+	Version  int
+	Seed     int64
+	Commands []Command
+	Hash     string
+}
+
+// Record appends the command to the replay.
+func (r *Replay) Record(c Command) {
+	if r != nil {
+		r.Commands = append(r.Commands, c)
+	}
+}
+
+// WriteFile writes the replay to the file at path.
+func (r *Replay) WriteFile(path string) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// ReadReplay reads the replay from the file at path.
+func ReadReplay(path string) (*Replay, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	r := &Replay{}
+	if err := json.Unmarshal(data, r); err != nil {
+		return nil, fmt.Errorf("replay: %w", err)
+	}
+	if r.Version != replayVersion {
+		return nil, fmt.Errorf("replay: unsupported version %d", r.Version)
+	}
+	return r, nil
+}
+
+// Simulate plays the replay without a window and returns the hash of
+// the final state.
+func (r *Replay) Simulate() string {
+	g := NewGame(r.Seed)
+	for _, c := range r.Commands {
+		g.Act(c)
+	}
+	return g.Hash()
+}
+
+// Playback plays a replay in the window.
+type Playback struct {
+	Replay *Replay
+	// Next is the index of the next command and Delay is the number
+	// of ticks between the commands.
+	Next   int
+	Delay  int
+	Paused bool
+	wait   int
+}
+
+// Update plays the next command when it is due. Space pauses, plus
+// and minus change the speed, and period steps while paused.
+func (pb *Playback) Update(g *Game) {
+	step := false
+	switch {
+	case justPressed(ebiten.KeySpace):
+		pb.Paused = !pb.Paused
+	case justPressed(ebiten.KeyEqual, ebiten.KeyNumpadAdd):
+		if pb.Delay > 0 {
+			pb.Delay--
+		}
+	case justPressed(ebiten.KeyMinus, ebiten.KeyNumpadSubtract):
+		pb.Delay++
+	case justPressed(ebiten.KeyPeriod):
+		step = pb.Paused
+	}
+	if pb.Next >= len(pb.Replay.Commands) {
+		return
+	}
+	if !step {
+		if pb.wait++; pb.Paused || pb.wait <= pb.Delay {
+			return
+		}
+	}
+	pb.wait = 0
+	g.Act(pb.Replay.Commands[pb.Next])
+	pb.Next++
+	if pb.Next == len(pb.Replay.Commands) {
+		if g.Hash() == pb.Replay.Hash {
+			g.Log.Add("The replay has ended with the recorded state.", colorNotice)
+		} else {
+			g.Log.Add("The replay has ended with a different state.", colorDanger)
+		}
+	}
+}
+
+// String returns the progress of the playback.
+func (pb *Playback) String() string {
+	s := fmt.Sprintf("Replay %d/%d delay %d", pb.Next, len(pb.Replay.Commands), pb.Delay)
+	if pb.Paused {
+		s += " paused"
+	}
+	return s
+}
+
+// replayMain runs the replay command:
+//
+//	cave replay [-headless] [-delay ticks] [file]
+func replayMain(args []string) {
+	fs := flag.NewFlagSet("replay", flag.ExitOnError)
+	headless := fs.Bool("headless", false, "verify the final state hash without a window")
+	delay := fs.Int("delay", 2, "number of ticks between the commands")
+	fs.Parse(args)
+	path := replayPath
+	if fs.NArg() > 0 {
+		path = fs.Arg(0)
+	}
+	r, err := ReadReplay(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *headless {
+		hash := r.Simulate()
+		fmt.Printf("%d commands, hash %s\n", len(r.Commands), hash)
+		if hash != r.Hash {
+			fmt.Printf("mismatch: recorded hash %s\n", r.Hash)
+			os.Exit(1)
+		}
+		return
+	}
+
+	game := NewGame(r.Seed)
+	game.Playback = &Playback{Replay: r, Delay: *delay}
+	run(game)
+}
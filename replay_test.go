@@ -0,0 +1,36 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestReplay(t *testing.T) {
+	g := NewGame(1)
+	if h := NewGame(1).Hash(); h != g.Hash() {
+		t.Fatalf("the same seed generated different games")
+	}
+	g = NewGame(1)
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 300 && g.Player.HP > 0; i++ {
+		c := Command{Action: MoveAction, Dir: XY{}.Neighbors()[rng.Intn(8)]}
+		switch rng.Intn(10) {
+		case 0:
+			c = Command{Action: PickUpAction}
+		case 1:
+			c = Command{Action: EquipAction, Item: rng.Intn(3)}
+		case 2:
+			c = Command{Action: PerkAction, Item: rng.Intn(3)}
+		}
+		g.Act(c)
+	}
+	if len(g.Replay.Commands) == 0 {
+		t.Fatalf("no commands were recorded")
+	}
+	want := g.Hash()
+	for i := 0; i < 2; i++ {
+		if got := g.Replay.Simulate(); got != want {
+			t.Fatalf("replay %d: hash %s, want %s", i, got, want)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package main
+
+import "math/rand"
+
+// RNG is the random number generator of the game. Its state is saved
+// with the game so that a loaded game continues the same sequence.
+var RNG = rand.New(&rngSource)
+
+var rngSource Source
+
+// Source is a splitmix64 random source whose whole state is its
+// exported value.
+type Source struct {
+	State uint64
+}
+
+// Seed implements the rand.Source interface.
+func (s *Source) Seed(seed int64) {
+	s.State = uint64(seed)
+}
+
+// Uint64 implements the rand.Source64 interface.
+func (s *Source) Uint64() uint64 {
+	s.State += 0x9e3779b97f4a7c15
+	z := s.State
+	z = (z ^ z>>30) * 0xbf58476d1ce4e5b9
+	z = (z ^ z>>27) * 0x94d049bb133111eb
+	return z ^ z>>31
+}
+
+// Int63 implements the rand.Source interface.
+func (s *Source) Int63() int64 {
+	return int64(s.Uint64() >> 1)
+}
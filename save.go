@@ -0,0 +1,496 @@
+package main
+
+import (
+	"compress/gzip"
+	"container/heap"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// saveVersion is the version of the save format written by Save.
+const saveVersion = 5
+
+// savePath is the file the game is saved to on quit.
+const savePath = "cave.sav"
+
+// migrations upgrade the raw fields of a save file from the version
+// at their index plus one to the next version.
+var migrations = []func(map[string]json.RawMessage) error{
+	// Version 2 writes the kinds of the effects by name and added the
+	// depth, which is 1 for the older saves.
+	func(raw map[string]json.RawMessage) error {
+		var depth int
+		if json.Unmarshal(raw["Depth"], &depth); depth < 1 {
+			raw["Depth"] = json.RawMessage("1")
+		}
+		return migrateEntities(raw, func(e map[string]json.RawMessage) error {
+			if e["Effects"] == nil {
+				return nil
+			}
+			var effects []map[string]json.RawMessage
+			if err := json.Unmarshal(e["Effects"], &effects); err != nil {
+				return err
+			}
+			for _, ef := range effects {
+				var k int
+				if json.Unmarshal(ef["Kind"], &k) != nil {
+					continue
+				}
+				if k < 0 || k >= int(numEffects) {
+					return fmt.Errorf("unknown effect %d", k)
+				}
+				ef["Kind"], _ = json.Marshal(EffectKind(k))
+			}
+			e["Effects"], _ = json.Marshal(effects)
+			return nil
+		})
+	},
+	// Version 3 added the perception of the player.
+	migratePlayer(func(data map[string]json.RawMessage) error {
+		var base []int
+		if err := json.Unmarshal(data["Base"], &base); err != nil {
+			return err
+		}
+		for len(base) <= int(PerceptionStat) {
+			base = append(base, 0)
+		}
+		base[PerceptionStat] = 3
+		data["Base"], _ = json.Marshal(base)
+		return nil
+	}),
+	// Version 4 added the experience level of the player.
+	migratePlayer(func(data map[string]json.RawMessage) error {
+		data["Level"] = json.RawMessage("1")
+		return nil
+	}),
+	// Version 5 requires the replay field. Saves from before the replay
+	// lack it and load without one; saves of version 4 may already
+	// hold a replay, which is kept.
+	func(raw map[string]json.RawMessage) error {
+		if _, ok := raw["Replay"]; !ok {
+			raw["Replay"] = json.RawMessage("null")
+		}
+		return nil
+	},
+}
+
+// migratePlayer returns a migration which upgrades the raw fields of
+// the saved player with f.
+func migratePlayer(f func(data map[string]json.RawMessage) error) func(map[string]json.RawMessage) error {
+	return func(raw map[string]json.RawMessage) error {
+		return migrateEntities(raw, func(e map[string]json.RawMessage) error {
+			if string(e["Type"]) != `"player"` {
+				return nil
+			}
+			var data map[string]json.RawMessage
+			if err := json.Unmarshal(e["Data"], &data); err != nil {
+				return err
+			}
+			if err := f(data); err != nil {
+				return err
+			}
+			e["Data"], _ = json.Marshal(data)
+			return nil
+		})
+	}
+}
+
+// migrateEntities upgrades the raw fields of every saved entity with f.
+func migrateEntities(raw map[string]json.RawMessage, f func(e map[string]json.RawMessage) error) error {
+	var entities []map[string]json.RawMessage
+	if err := json.Unmarshal(raw["Entities"], &entities); err != nil {
+		return err
+	}
+	for _, e := range entities {
+		if err := f(e); err != nil {
+			return err
+		}
+	}
+	var err error
+	raw["Entities"], err = json.Marshal(entities)
+	return err
+}
+
+// entityTypes is the registry of the saved entity types by name.
+var entityTypes = map[string]func() Entity{
+	"player":  func() Entity { return &Player{} },
+	"monster": func() Entity { return &Monster{} },
+	"miner":   func() Entity { return &Miner{} },
+	"item":    func() Entity { return &Item{} },
+	"corpse":  func() Entity { return &Corpse{} },
+}
+
+// typeName returns the registered name of the type of the entity.
+func typeName(e Entity) (string, bool) {
+	t := reflect.TypeOf(e)
+	for name, f := range entityTypes {
+		if reflect.TypeOf(f()) == t {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// Restorer is implemented by any entity that has to restore the
+// state not saved with it after loading.
+type Restorer interface {
+	Restore(s *State)
+}
+
+// saveFile is the saved game.
+type saveFile struct {
+	Version  int
+	Turn     int
+	Depth    int
+	NextID   ID
+	RNG      Source
+	Theme    string
+	Palette  []string
+	Tiles    [][3]int
+	Entities []savedEntity
+	Log      []Message
+	Replay   *Replay
+}
+
+// savedEntity is an entity with its state kept by State.
+type savedEntity struct {
+	ID      ID
+	Type    string
+	Energy  int
+	Effects []Effect `json:",omitempty"`
+	Data    json.RawMessage
+}
+
+// Save writes the game to w.
+func (g *Game) Save(w io.Writer) error {
+	f, err := g.snapshot()
+	if err != nil {
+		return err
+	}
+	f.Log = g.Log.Messages
+	f.Replay = g.Replay
+	zw := gzip.NewWriter(w)
+	if err := json.NewEncoder(zw).Encode(f); err != nil {
+		return fmt.Errorf("save: %w", err)
+	}
+	return zw.Close()
+}
+
+// Hash returns the hash of the state of the world.
+func (g *Game) Hash() string {
+	f, err := g.snapshot()
+	if err != nil {
+		panic(err)
+	}
+	data, err := json.Marshal(f)
+	if err != nil {
+		panic(err)
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(data))
+}
+
+// snapshot returns the saved form of the world.
+func (g *Game) snapshot() (saveFile, error) {
+	s := g.State
+	f := saveFile{
+		Version: saveVersion,
+		Turn:    s.Turn,
+		Depth:   g.Depth,
+		NextID:  nextID,
+		RNG:     rngSource,
+	}
+	for name, th := range Themes {
+		if th == g.Theme {
+			f.Theme = name
+		}
+	}
+
+	// Tiles are saved by name to survive changes of the registry.
+	index := map[Tile]int{}
+	for _, p := range sortedPoints(s.Tiles) {
+		t := s.Tiles[p]
+		i, ok := index[t]
+		if !ok {
+			i = len(f.Palette)
+			index[t] = i
+			f.Palette = append(f.Palette, t.Def().Name)
+		}
+		f.Tiles = append(f.Tiles, [3]int{p.X, p.Y, i})
+	}
+
+	for _, id := range s.sortedIDs() {
+		e := s.Entities[id]
+		name, ok := typeName(e)
+		if !ok {
+			return f, fmt.Errorf("save: unregistered entity type %T", e)
+		}
+		data, err := json.Marshal(e)
+		if err != nil {
+			return f, fmt.Errorf("save: %w", err)
+		}
+		f.Entities = append(f.Entities, savedEntity{id, name, s.energy[id], s.effects[id], data})
+	}
+	return f, nil
+}
+
+// Load replaces the game with the one read from r.
+func (g *Game) Load(r io.Reader) error {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return fmt.Errorf("load: %w", err)
+	}
+	var raw map[string]json.RawMessage
+	if err := json.NewDecoder(zr).Decode(&raw); err != nil {
+		return fmt.Errorf("load: %w", err)
+	}
+	var version int
+	if err := json.Unmarshal(raw["Version"], &version); err != nil {
+		return fmt.Errorf("load: version: %w", err)
+	}
+	if version < 1 || version > saveVersion {
+		return fmt.Errorf("load: unsupported version %d", version)
+	}
+	for ; version < saveVersion; version++ {
+		if err := migrations[version-1](raw); err != nil {
+			return fmt.Errorf("load: migrate from version %d: %w", version, err)
+		}
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return fmt.Errorf("load: %w", err)
+	}
+	var f saveFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return fmt.Errorf("load: %w", err)
+	}
+
+	s := NewState()
+	s.Bounds = g.Bounds
+	s.Turn = f.Turn
+	tiles := make([]Tile, len(f.Palette))
+	for i, name := range f.Palette {
+		t, ok := TileByName(name)
+		if !ok {
+			return fmt.Errorf("load: unknown tile %q", name)
+		}
+		tiles[i] = t
+	}
+	for _, t := range f.Tiles {
+		if t[2] < 0 || t[2] >= len(tiles) {
+			return fmt.Errorf("load: invalid tile index %d", t[2])
+		}
+		s.Tiles[XY{t[0], t[1]}] = tiles[t[2]]
+	}
+
+	var pl *Player
+	for _, se := range f.Entities {
+		newEntity, ok := entityTypes[se.Type]
+		if !ok {
+			return fmt.Errorf("load: unknown entity type %q", se.Type)
+		}
+		e := newEntity()
+		if err := json.Unmarshal(se.Data, e); err != nil {
+			return fmt.Errorf("load: %s %d: %w", se.Type, se.ID, err)
+		}
+		s.insert(se.ID, e)
+		s.energy[se.ID] = se.Energy
+		if len(se.Effects) > 0 {
+			s.effects[se.ID] = se.Effects
+		}
+		if p, ok := e.(*Player); ok {
+			pl = p
+		}
+	}
+	if pl == nil {
+		return errors.New("load: no player")
+	}
+	for _, e := range s.Entities {
+		if r, ok := e.(Restorer); ok {
+			r.Restore(s)
+		}
+	}
+	for _, id := range s.sortedIDs() {
+		if s.Ready(id) {
+			heap.Push(&s.ready, id)
+		}
+	}
+
+	g.State = s
+	g.Player = pl
+	g.Depth = f.Depth
+	g.Theme = Themes[f.Theme]
+	g.Log = &MessageLog{f.Log}
+	g.Replay = f.Replay
+	g.Screen = nil
+	nextID = f.NextID
+	rngSource = f.RNG
+
+	// Resume the turn of the player.
+	id, _ := s.ID(pl)
+	s.RunUntil(id)
+	s.UpdateLight()
+	pl.UpdateFOV()
+	s.Events.Subscribe(g.Notify)
+	return nil
+}
+
+// SaveFile saves the game to the file at path.
+func (g *Game) SaveFile(path string) error {
+	tmp := path + ".tmp"
+	w, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if err := g.Save(w); err != nil {
+		w.Close()
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// LoadFile loads the game from the file at path.
+func (g *Game) LoadFile(path string) error {
+	r, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	return g.Load(r)
+}
+
+// savedPlayer is the saved form of the player. The explored points
+// are listed and the equipment refers to the inventory by index.
+type savedPlayer struct {
+	*player
+	Explored  []XY
+	Equipment [numSlots]int
+}
+
+type player Player
+
+func (p *Player) MarshalJSON() ([]byte, error) {
+	sp := savedPlayer{player: (*player)(p), Explored: sortedPoints(p.Explored)}
+	for i, it := range p.Equipment {
+		sp.Equipment[i] = -1
+		for j, carried := range p.Inventory.Items {
+			if it != nil && it == carried {
+				sp.Equipment[i] = j
+			}
+		}
+	}
+	return json.Marshal(sp)
+}
+
+func (p *Player) UnmarshalJSON(data []byte) error {
+	sp := savedPlayer{player: (*player)(p)}
+	if err := json.Unmarshal(data, &sp); err != nil {
+		return err
+	}
+	p.Explored = map[XY]bool{}
+	for _, q := range sp.Explored {
+		p.Explored[q] = true
+	}
+	for i, j := range sp.Equipment {
+		p.Equipment[i] = nil
+		if j >= 0 && j < len(p.Inventory.Items) {
+			p.Equipment[i] = p.Inventory.Items[j]
+		}
+	}
+	return nil
+}
+
+// Restore implements the Restorer interface.
+func (p *Player) Restore(s *State) {
+	p.State = s
+	p.Sight = Shadowcasting{}
+	p.FOV = map[XY]bool{}
+	p.Recompute()
+}
+
+// savedMonster is the saved form of a monster with its kind by name.
+type savedMonster struct {
+	*monster
+	Kind string
+}
+
+type monster Monster
+
+func (m *Monster) MarshalJSON() ([]byte, error) {
+	return json.Marshal(savedMonster{(*monster)(m), m.Kind.Name})
+}
+
+func (m *Monster) UnmarshalJSON(data []byte) error {
+	sm := savedMonster{monster: (*monster)(m)}
+	if err := json.Unmarshal(data, &sm); err != nil {
+		return err
+	}
+	if m.Kind = Kinds[sm.Kind]; m.Kind == nil {
+		return fmt.Errorf("unknown monster kind %q", sm.Kind)
+	}
+	return nil
+}
+
+// Restore implements the Restorer interface.
+func (m *Monster) Restore(s *State) {
+	m.State = s
+}
+
+// Restore implements the Restorer interface.
+func (m *Miner) Restore(s *State) {
+	m.State = s
+}
+
+// savedItem is the saved form of an item with its kind by name.
+type savedItem struct {
+	*item
+	Kind string
+}
+
+type item Item
+
+func (it *Item) MarshalJSON() ([]byte, error) {
+	return json.Marshal(savedItem{(*item)(it), it.Kind.Name})
+}
+
+func (it *Item) UnmarshalJSON(data []byte) error {
+	si := savedItem{item: (*item)(it)}
+	if err := json.Unmarshal(data, &si); err != nil {
+		return err
+	}
+	if it.Kind = Items[si.Kind]; it.Kind == nil {
+		return fmt.Errorf("unknown item kind %q", si.Kind)
+	}
+	return nil
+}
+
+// ContinueScreen offers to continue the saved game at startup.
+type ContinueScreen struct{}
+
+func (ContinueScreen) Update(g *Game) bool {
+	switch {
+	case justPressed(ebiten.KeyC, ebiten.KeyEnter):
+		if err := g.LoadFile(savePath); err != nil {
+			g.Log.Add(fmt.Sprintf("Cannot continue: %v.", err), colorDanger)
+		}
+		return false
+	case justPressed(ebiten.KeyN, ebiten.KeyEscape):
+		return false
+	}
+	return true
+}
+
+func (ContinueScreen) Draw(g *Game) {
+	g.Terminal.Text(XY{2, 2}, "A saved game was found.", colorNotice)
+	g.Terminal.Text(XY{2, 4}, "C  Continue", colorInfo)
+	g.Terminal.Text(XY{2, 5}, "N  New game", colorInfo)
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"testing"
+)
+
+func TestSaveLoad(t *testing.T) {
+	s, p := arena(10, XY{5, 5})
+	p.Inventory.Add(NewItem(Items["stone"], XY{}, 3))
+	p.Inventory.Add(NewItem(Items["sword"], XY{}, 1))
+	p.Equip(p.Inventory.Items[1])
+	p.Explored[XY{1, 1}] = true
+	m := NewMonster(Kinds["goblin"], XY{2, 2}, s)
+	s.Add(m)
+	m.HP = 3
+	s.Drop(NewItem(Items["dagger"], XY{7, 7}, 1), XY{7, 7})
+	s.AddEffect(m, Effect{Kind: Poisoned, Turns: 3})
+	s.Turn = 42
+	pid, _ := s.ID(p)
+	s.RunUntil(pid)
+	g := &Game{State: s, Player: p, Theme: &RoughTheme, Log: &MessageLog{}}
+	g.Log.Add("Hello.", colorInfo)
+
+	var buf bytes.Buffer
+	if err := g.Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+	want := RNG.Int63()
+	rngBefore := rngSource
+	RNG.Int63()
+	rngSource = rngBefore
+	id := nextID
+	nextID = 0
+
+	h := &Game{}
+	if err := h.Load(bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+	if nextID != id {
+		t.Errorf("nextID = %d, want %d", nextID, id)
+	}
+	if got := RNG.Int63(); got != want {
+		t.Errorf("RNG state was not restored")
+	}
+	if hid, _ := h.State.ID(h.Player); hid != pid || !h.State.Ready(hid) {
+		t.Errorf("it is not the turn of the player")
+	}
+	q := h.Player
+	if q.XY != p.XY || q.State != h.State || !q.Explored[XY{1, 1}] || q.Attack != p.Attack {
+		t.Errorf("player = %+v", q)
+	}
+	if q.Equipment[WeaponSlot] != q.Inventory.Items[1] {
+		t.Errorf("equipment is not in the inventory")
+	}
+	if h.State.Turn != s.Turn || h.Theme != &RoughTheme || len(h.Log.Messages) != 1 {
+		t.Errorf("turn %d, theme %v, log %v", h.State.Turn, h.Theme, h.Log.Messages)
+	}
+	if len(h.State.Tiles) != len(s.Tiles) || h.State.Tiles[XY{3, 3}] != Floor {
+		t.Errorf("tiles were not restored")
+	}
+	mid, _ := s.ID(m)
+	n, ok := h.State.Entities[mid].(*Monster)
+	if !ok || n.Kind != Kinds["goblin"] || n.HP != m.HP || n.XY != m.XY || n.State != h.State {
+		t.Fatalf("monster = %+v", h.State.Entities[mid])
+	}
+	if !h.State.HasEffect(n, Poisoned) {
+		t.Errorf("effects were not restored")
+	}
+	if items := h.State.ItemsAt(XY{7, 7}); len(items) != 1 || items[0].Kind != Items["dagger"] {
+		t.Errorf("items at {7 7} = %v", items)
+	}
+}
+
+func TestLoadVersion(t *testing.T) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write([]byte(`{"Version": 999}`))
+	zw.Close()
+	if err := (&Game{}).Load(&buf); err == nil {
+		t.Errorf("loaded a save from the future")
+	}
+}
+
+func TestLoadMigration(t *testing.T) {
+	s, p := arena(3, XY{1, 1})
+	s.AddEffect(p, Effect{Kind: Hasted, Turns: 5})
+	g := &Game{State: s, Player: p, Depth: 1, Log: &MessageLog{}}
+	var buf bytes.Buffer
+	if err := g.Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	// Turn the save into a version 1 save without the depth, replay,
+	// perception and level and with the effect kinds by number.
+	zr, err := gzip.NewReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var f map[string]any
+	if err := json.NewDecoder(zr).Decode(&f); err != nil {
+		t.Fatal(err)
+	}
+	f["Version"] = 1
+	delete(f, "Depth")
+	delete(f, "Replay")
+	for _, e := range f["Entities"].([]any) {
+		e := e.(map[string]any)
+		if effects, ok := e["Effects"].([]any); ok {
+			for _, ef := range effects {
+				ef.(map[string]any)["Kind"] = int(Hasted)
+			}
+		}
+		if e["Type"] != "player" {
+			continue
+		}
+		data := e["Data"].(map[string]any)
+		data["Base"] = data["Base"].([]any)[:PerceptionStat]
+		delete(data, "Level")
+	}
+	buf.Reset()
+	zw := gzip.NewWriter(&buf)
+	json.NewEncoder(zw).Encode(f)
+	zw.Close()
+
+	h := &Game{}
+	if err := h.Load(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if got := h.Player.Effective[PerceptionStat]; got != 3 || h.Player.Level != 1 {
+		t.Errorf("perception = %d, level = %d, want 3 and 1", got, h.Player.Level)
+	}
+	if h.Depth != 1 || !h.State.HasEffect(h.Player, Hasted) {
+		t.Errorf("depth = %d, effects = %v, want 1 and hasted", h.Depth, h.State.Effects(h.Player))
+	}
+	if h.Replay != nil {
+		t.Errorf("an old save has a replay")
+	}
+	h.Act(Command{Action: MoveAction})
+}
+
+func TestLoadMigrationKeepsReplay(t *testing.T) {
+	s, p := arena(3, XY{1, 1})
+	g := &Game{State: s, Player: p, Depth: 1, Log: &MessageLog{}}
+	g.Replay = &Replay{Seed: 7, Commands: []Command{{Action: MoveAction, Dir: East}}}
+	var buf bytes.Buffer
+	if err := g.Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	// Stamp the save with version 4, which could already hold a replay.
+	zr, err := gzip.NewReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var f map[string]any
+	if err := json.NewDecoder(zr).Decode(&f); err != nil {
+		t.Fatal(err)
+	}
+	f["Version"] = 4
+	buf.Reset()
+	zw := gzip.NewWriter(&buf)
+	json.NewEncoder(zw).Encode(f)
+	zw.Close()
+
+	h := &Game{}
+	if err := h.Load(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if h.Replay == nil || h.Replay.Seed != 7 || len(h.Replay.Commands) != 1 {
+		t.Errorf("replay = %+v, want the saved one", h.Replay)
+	}
+}
@@ -0,0 +1,140 @@
+package main
+
+import "container/heap"
+
+// Speed and action cost of an ordinary entity. An entity gains its
+// speed in energy every turn and acts when it has at least
+// NormalCost energy.
+const (
+	NormalSpeed = 100
+	NormalCost  = 100
+)
+
+// Speeder is implemented by entities whose speed differs from
+// NormalSpeed.
+type Speeder interface {
+	Speed() int
+}
+
+// ActionCoster is implemented by entities whose last action cost
+// differs from NormalCost.
+type ActionCoster interface {
+	ActionCost() int
+}
+
+// speed returns the speed of the entity, at least 1 so that slowed
+// entities still act eventually.
+func speed(e Entity) int {
+	if s, ok := e.(Speeder); ok {
+		if v := s.Speed(); v > 1 {
+			return v
+		}
+		return 1
+	}
+	return NormalSpeed
+}
+
+// actionCost returns the cost of the last action of the entity.
+func actionCost(e Entity) int {
+	if c, ok := e.(ActionCoster); ok {
+		return c.ActionCost()
+	}
+	return NormalCost
+}
+
+// Spend deducts the cost of the last action from the energy of the
+// entity with the given ID if it was ready to act.
+func (s *State) Spend(id ID) {
+	e, ok := s.Entities[id]
+	if !ok || !s.Ready(id) {
+		return
+	}
+	s.energy[id] -= actionCost(e)
+	if s.Ready(id) {
+		heap.Push(&s.ready, id)
+	}
+}
+
+// Ready reports whether the entity with the given ID has enough
+// energy to act.
+func (s *State) Ready(id ID) bool {
+	return s.energy[id] >= NormalCost
+}
+
+// next pops the next ready entity from the turn order.
+func (s *State) next() (ID, bool) {
+	for s.ready.Len() > 0 {
+		id := heap.Pop(&s.ready).(ID)
+		if _, ok := s.Entities[id]; ok && s.Ready(id) {
+			return id, true
+		}
+	}
+	return 0, false
+}
+
+// Step lets the next ready entity act and spend its energy. If no
+// entity is ready, every updater gains energy and the turn advances.
+func (s *State) Step() {
+	if id, ok := s.next(); ok {
+		if u, ok := s.Entities[id].(Updater); ok {
+			u.Update()
+		}
+		s.Spend(id)
+		return
+	}
+	s.Turn++
+	s.tickEffects()
+	for id, e := range s.Entities {
+		if _, ok := e.(Updater); !ok {
+			continue
+		}
+		s.energy[id] += speed(e)
+		if s.Ready(id) {
+			heap.Push(&s.ready, id)
+		}
+	}
+}
+
+// RunUntil lets the other entities act until it is the turn of the
+// entity with the given ID or it leaves the world. The entity is
+// expected to act and Spend its energy afterwards.
+func (s *State) RunUntil(id ID) {
+	for {
+		if _, ok := s.Entities[id]; !ok {
+			return
+		}
+		if next, ok := s.next(); ok {
+			if next == id {
+				return
+			}
+			heap.Push(&s.ready, next)
+		}
+		s.Step()
+	}
+}
+
+// schedule is a priority queue of the ready entities in the order of
+// decreasing energy, then increasing ID.
+type schedule struct {
+	ids    []ID
+	energy map[ID]int
+}
+
+func (q schedule) Len() int { return len(q.ids) }
+
+func (q schedule) Less(i, j int) bool {
+	a, b := q.ids[i], q.ids[j]
+	if q.energy[a] != q.energy[b] {
+		return q.energy[a] > q.energy[b]
+	}
+	return a < b
+}
+
+func (q schedule) Swap(i, j int) { q.ids[i], q.ids[j] = q.ids[j], q.ids[i] }
+func (q *schedule) Push(x any)   { q.ids = append(q.ids, x.(ID)) }
+
+func (q *schedule) Pop() any {
+	x := q.ids[len(q.ids)-1]
+	q.ids = q.ids[:len(q.ids)-1]
+	return x
+}
@@ -0,0 +1,63 @@
+package main
+
+import "testing"
+
+// counter is an entity that counts its actions.
+type counter struct {
+	XY
+	speed int
+	acts  *[]int
+	id    int
+}
+
+func (c *counter) Update()        { *c.acts = append(*c.acts, c.id) }
+func (c *counter) Symbol() Symbol { return Symbol{} }
+func (c *counter) Speed() int     { return c.speed }
+
+func TestScheduler(t *testing.T) {
+	s := NewState()
+	acts := []int{}
+	player := s.Add(&counter{speed: NormalSpeed, acts: &acts, id: 0})
+	s.Add(&counter{speed: 2 * NormalSpeed, acts: &acts, id: 1})
+	s.Add(&counter{speed: NormalSpeed / 2, acts: &acts, id: 2})
+
+	const turns = 10
+	for turn := 0; turn < turns; turn++ {
+		s.RunUntil(player)
+		s.Spend(player)
+	}
+	count := map[int]int{}
+	for _, id := range acts {
+		count[id]++
+	}
+	// The counts depend on the phase of the turn the player acts at.
+	if count[0] != 0 || abs(count[1]-2*turns) > 1 || abs(count[2]-turns/2) > 1 {
+		t.Errorf("acts = %v, want about %d fast and %d slow", acts, 2*turns, turns/2)
+	}
+	if s.Turn != turns {
+		t.Errorf("Turn = %d, want %d", s.Turn, turns)
+	}
+}
+
+// stone is an entity which never acts.
+type stone struct{ XY }
+
+func (stone) Symbol() Symbol { return Symbol{} }
+
+func TestSchedulerSkipsInert(t *testing.T) {
+	s := NewState()
+	acts := []int{}
+	player := s.Add(&counter{speed: NormalSpeed, acts: &acts, id: 0})
+	rock := s.Add(&stone{})
+	s.Add(&counter{speed: -50, acts: &acts, id: 1})
+	for turn := 0; turn < 2*NormalCost; turn++ {
+		s.RunUntil(player)
+		s.Spend(player)
+	}
+	if s.energy[rock] != 0 {
+		t.Errorf("the stone gained %d energy", s.energy[rock])
+	}
+	if len(acts) == 0 {
+		t.Errorf("the entity with a negative speed never acted")
+	}
+}
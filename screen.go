@@ -0,0 +1,66 @@
+package main
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// Screen is implemented by any full window view shown instead of the
+// map, such as the message history.
+type Screen interface {
+	// Update handles the input. Returns false if the screen is closed.
+	Update(g *Game) bool
+	Draw(g *Game)
+}
+
+// justPressed reports whether any of the keys was pressed in this
+// tick.
+func justPressed(ks ...ebiten.Key) bool {
+	for _, k := range ks {
+		if inpututil.IsKeyJustPressed(k) {
+			return true
+		}
+	}
+	return false
+}
+
+// HistoryScreen shows the message log scrollback.
+type HistoryScreen struct {
+	// Scroll is the number of messages scrolled back from the last.
+	Scroll int
+}
+
+func (h *HistoryScreen) Update(g *Game) bool {
+	page := g.Terminal.Dimensions.Y - 2
+	switch {
+	case justPressed(ebiten.KeyEscape, ebiten.KeyM):
+		return false
+	case justPressed(ebiten.KeyUp, ebiten.KeyNumpad8, ebiten.KeyW):
+		h.Scroll++
+	case justPressed(ebiten.KeyDown, ebiten.KeyNumpad2, ebiten.KeyX):
+		h.Scroll--
+	case justPressed(ebiten.KeyPageUp, ebiten.KeyNumpad9):
+		h.Scroll += page
+	case justPressed(ebiten.KeyPageDown, ebiten.KeyNumpad3):
+		h.Scroll -= page
+	}
+	if max := len(g.Log.Messages) - page; h.Scroll > max {
+		h.Scroll = max
+	}
+	if h.Scroll < 0 {
+		h.Scroll = 0
+	}
+	return true
+}
+
+func (h *HistoryScreen) Draw(g *Game) {
+	g.Terminal.Text(XY{1, 0}, "Message history (Esc to close)", colorNotice)
+	page := g.Terminal.Dimensions.Y - 2
+	msgs := g.Log.Messages[:len(g.Log.Messages)-h.Scroll]
+	if len(msgs) > page {
+		msgs = msgs[len(msgs)-page:]
+	}
+	for i, m := range msgs {
+		g.Terminal.Text(XY{1, 2 + i}, m.String(), m.Color)
+	}
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+//go:embed spawns.json
+var spawnsData []byte
+
+func init() {
+	t, err := ReadSpawnTable(bytes.NewReader(spawnsData))
+	if err != nil {
+		panic(err)
+	}
+	Spawns = t
+}
+
+// Spawns is the spawn table of the game.
+var Spawns *SpawnTable
+
+// SpawnTable defines the population of the generated levels.
+type SpawnTable struct {
+	// Count is the number of creatures spawned on a level and
+	// MinDistance is their minimum distance from the start of the
+	// player.
+	Count       int
+	MinDistance int
+	// Rooms are the weighted tags given to the rooms of a level. The
+	// empty tag leaves a room untagged.
+	Rooms []struct {
+		Tag    string
+		Weight int
+	}
+	Spawns []Spawn
+}
+
+// Spawn is a weighted entry of the spawn table. An entry with Rooms
+// spawns only in the rooms with one of the tags, any other entry
+// spawns only outside of the tagged rooms. Empty Generators match any
+// generator and zero depths are unbounded.
+type Spawn struct {
+	Kind       string
+	Weight     int
+	MinDepth   int
+	MaxDepth   int
+	Generators []string
+	Rooms      []string
+}
+
+// ReadSpawnTable reads a JSON spawn table from r.
+func ReadSpawnTable(r io.Reader) (*SpawnTable, error) {
+	t := &SpawnTable{}
+	if err := json.NewDecoder(r).Decode(t); err != nil {
+		return nil, fmt.Errorf("spawns: %w", err)
+	}
+	for _, sp := range t.Spawns {
+		if Kinds[sp.Kind] == nil && spawners[sp.Kind] == nil {
+			return nil, fmt.Errorf("spawns: unknown kind %q", sp.Kind)
+		}
+		if sp.Weight <= 0 {
+			return nil, fmt.Errorf("spawns: %s: weight must be positive", sp.Kind)
+		}
+	}
+	return t, nil
+}
+
+// spawners create the creatures of the spawn table which are not
+// monster kinds by name.
+var spawners = map[string]func(p XY, s *State, l *Level) Entity{
+	"miner": func(p XY, s *State, l *Level) Entity {
+		return &Miner{p, l.Bounds.Inset(1), RNG.Intn(1000), s, minerStats}
+	},
+}
+
+// spawn returns a new creature of the named kind.
+func spawn(name string, p XY, s *State, l *Level) (Entity, bool) {
+	if k, ok := Kinds[name]; ok {
+		return NewMonster(k, p, s), true
+	}
+	if f, ok := spawners[name]; ok {
+		return f(p, s, l), true
+	}
+	return nil, false
+}
+
+// Level describes a generated map to populate.
+type Level struct {
+	Depth     int
+	Generator string
+	Bounds    Rect
+	Rooms     []RoomTag
+}
+
+// RoomTag is a room of a level with its tag.
+type RoomTag struct {
+	Rect
+	Tag string
+}
+
+// TagRooms returns the rooms tagged by the weights of the table.
+func (t *SpawnTable) TagRooms(rooms []Rect) []RoomTag {
+	total := 0
+	for _, r := range t.Rooms {
+		total += r.Weight
+	}
+	tagged := []RoomTag{}
+	for _, room := range rooms {
+		tag := ""
+		if total > 0 {
+			n := RNG.Intn(total)
+			for _, r := range t.Rooms {
+				if n -= r.Weight; n < 0 {
+					tag = r.Tag
+					break
+				}
+			}
+		}
+		tagged = append(tagged, RoomTag{room, tag})
+	}
+	return tagged
+}
+
+// Populate spawns the creatures of the table on the level. They are
+// placed on free floor at least MinDistance steps away from start.
+func (t *SpawnTable) Populate(s *State, l *Level, start XY) {
+	opt := PathOptions{Diagonal: true, OpenDoors: true}
+	dist := s.DijkstraMap(map[XY]float64{start: 0}, opt)
+	floor := []XY{}
+	for p, c := range dist {
+		if c >= float64(t.MinDistance) && s.Tiles[p] == Floor {
+			floor = append(floor, p)
+		}
+	}
+	sortPoints(floor)
+
+	for i := 0; i < t.Count; i++ {
+		// Find the places of the entries for the level.
+		entries := []Spawn{}
+		places := [][]XY{}
+		total := 0
+		for _, sp := range t.Spawns {
+			if !sp.matches(l) {
+				continue
+			}
+			ps := []XY{}
+			for _, p := range floor {
+				if len(s.at[p]) == 0 && sp.fits(p, l) {
+					ps = append(ps, p)
+				}
+			}
+			if len(ps) > 0 {
+				entries = append(entries, sp)
+				places = append(places, ps)
+				total += sp.Weight
+			}
+		}
+		if total == 0 {
+			return
+		}
+
+		n := RNG.Intn(total)
+		for j, sp := range entries {
+			if n -= sp.Weight; n >= 0 {
+				continue
+			}
+			p := places[j][RNG.Intn(len(places[j]))]
+			if e, ok := spawn(sp.Kind, p, s, l); ok {
+				s.Add(e)
+			}
+			break
+		}
+	}
+}
+
+// matches reports whether the entry can spawn on the level.
+func (sp Spawn) matches(l *Level) bool {
+	if sp.MinDepth > 0 && l.Depth < sp.MinDepth || sp.MaxDepth > 0 && l.Depth > sp.MaxDepth {
+		return false
+	}
+	return len(sp.Generators) == 0 || in(sp.Generators, l.Generator)
+}
+
+// fits reports whether the entry can spawn at p with respect to the
+// tags of the rooms.
+func (sp Spawn) fits(p XY, l *Level) bool {
+	for _, r := range l.Rooms {
+		if r.Tag != "" && p.In(r.Rect) {
+			return in(sp.Rooms, r.Tag)
+		}
+	}
+	return len(sp.Rooms) == 0
+}
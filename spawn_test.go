@@ -0,0 +1,101 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestKinds(t *testing.T) {
+	for _, sp := range Spawns.Spawns {
+		if Kinds[sp.Kind] == nil && spawners[sp.Kind] == nil {
+			t.Errorf("spawn table refers to unknown kind %q", sp.Kind)
+		}
+	}
+	rat := Kinds["rat"]
+	if rat.Speed != 120 || rat.Stats.MaxHP != 3 || rat.Symbol.Char != 'r' || rat.Inflicts[0].Kind != Poisoned {
+		t.Errorf("rat = %+v", rat)
+	}
+	if b, ok := rat.Behaviors[0].(Flee); !ok || b.Below != 0.5 {
+		t.Errorf("rat behaviors = %v", rat.Behaviors)
+	}
+	if b, ok := Kinds["sentry"].Behaviors[0].(Chase); !ok || b.Leash != 8 {
+		t.Errorf("sentry behaviors = %v", Kinds["sentry"].Behaviors)
+	}
+	if _, ok := Kinds["bat"].Sight.(Permissive); !ok || Kinds["rat"].Sight != nil {
+		t.Errorf("bat sight = %v, rat sight = %v", Kinds["bat"].Sight, Kinds["rat"].Sight)
+	}
+	err := RegisterKinds(strings.NewReader(`[{"name": "x", "behaviors": ["dance"]}]`))
+	if err == nil {
+		t.Errorf("registered a kind with an unknown behavior")
+	}
+	err = RegisterKinds(strings.NewReader(`[{"name": "x", "sight": "x-ray"}]`))
+	if err == nil {
+		t.Errorf("registered a kind with an unknown sight")
+	}
+	_, err = ReadSpawnTable(strings.NewReader(`{"spawns": [{"kind": "ratt", "weight": 1}]}`))
+	if err == nil {
+		t.Errorf("read a spawn table with an unknown kind")
+	}
+}
+
+func TestPopulate(t *testing.T) {
+	s, p := arena(30, XY{29, 29})
+	table, err := ReadSpawnTable(strings.NewReader(`{
+		"count": 40,
+		"minDistance": 10,
+		"spawns": [
+			{"kind": "rat", "weight": 1, "rooms": ["lair"]},
+			{"kind": "goblin", "weight": 1, "generators": ["cave"]},
+			{"kind": "orc", "weight": 1, "minDepth": 2}
+		]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lair := Rect{0, 0, 5, 5}
+	table.Populate(s, &Level{1, "cave", Rect{0, 0, 30, 30}, []RoomTag{{lair, "lair"}}}, p.XY)
+
+	dist := s.DijkstraMap(map[XY]float64{p.XY: 0}, PathOptions{Diagonal: true})
+	n := 0
+	for _, e := range s.Entities {
+		m, ok := e.(*Monster)
+		if !ok {
+			continue
+		}
+		n++
+		if dist[m.XY] < 10 {
+			t.Errorf("%s at %v is too close to the player", m.Kind.Name, m.XY)
+		}
+		switch m.Kind.Name {
+		case "rat":
+			if !m.XY.In(lair) {
+				t.Errorf("rat at %v is out of the lair", m.XY)
+			}
+		case "goblin":
+			if m.XY.In(lair) {
+				t.Errorf("goblin at %v is in the lair", m.XY)
+			}
+		default:
+			t.Errorf("%s spawned at depth 1", m.Kind.Name)
+		}
+		if len(s.EntitiesAt(m.XY)) != 1 {
+			t.Errorf("%v is occupied twice", m.XY)
+		}
+	}
+	if n != 40 {
+		t.Errorf("spawned %d monsters, want 40", n)
+	}
+}
+
+func TestLoot(t *testing.T) {
+	s, _ := arena(5, XY{0, 0})
+	k := *Kinds["goblin"]
+	k.Loot = []Loot{{"stone", 1, 3}}
+	m := NewMonster(&k, XY{2, 2}, s)
+	s.Add(m)
+	s.Kill(m)
+	items := s.ItemsAt(XY{2, 2})
+	if len(items) != 1 || items[0].Kind != Items["stone"] || items[0].Count > 3 {
+		t.Errorf("loot = %v", items)
+	}
+}
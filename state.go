@@ -1,55 +1,171 @@
 package main
 
-import (
-	"math/rand"
-	"sort"
-)
+import "sort"
 
 // State represents a game state.
 type State struct {
 	Tiles    map[XY]Tile
 	Entities map[ID]Entity
-	at       map[XY][]ID
+	Light    Lightmap
+	Turn     int
+	Events   Bus
+
+	// Bounds are the bounds of the map. The walls on and outside of
+	// its border cannot be dug.
+	Bounds Rect
+
+	// Lifecycle hooks called after an entity is added, before it is
+	// removed and after it is moved.
+	OnAdd    []func(id ID, e Entity)
+	OnRemove []func(id ID, e Entity)
+	OnMove   []func(id ID, e Entity, from XY)
+
+	// Spatial index of the entities.
+	ids map[Entity]ID
+	pos map[ID]XY
+	at  map[XY][]ID
+
+	// Active status effects of the entities.
+	effects map[ID][]Effect
+
+	// Energy and turn order of the entities.
+	energy map[ID]int
+	ready  schedule
 }
 
 // NewState returns a new empty State.
 func NewState() *State {
-	return &State{
+	s := &State{
 		Tiles:    map[XY]Tile{},
 		Entities: map[ID]Entity{},
+		Light:    Lightmap{},
+		ids:      map[Entity]ID{},
+		pos:      map[ID]XY{},
 		at:       map[XY][]ID{},
 	}
-}
-
-// Update updates all entities except from the specified ones.
-func (s *State) Update(except ...ID) {
-	for id, e := range s.Entities {
-		if !in(except, id) {
-			e.Update()
-		}
-	}
-	s.at = map[XY][]ID{}
-	for id, e := range s.Entities {
-		p := e.Pos()
-		s.at[p] = append(s.at[p], id)
-	}
+	s.effects = map[ID][]Effect{}
+	s.energy = map[ID]int{}
+	s.ready = schedule{energy: s.energy}
+	s.OnAdd = append(s.OnAdd, func(id ID, e Entity) {
+		s.Events.Publish(Spawned{id, e})
+	})
+	s.OnMove = append(s.OnMove, func(id ID, e Entity, from XY) {
+		s.Events.Publish(Moved{id, e, from})
+	})
+	return s
 }
 
 // Add adds the specified entity to the world.
 // Returns a new ID of the added entity.
 func (s *State) Add(e Entity) ID {
 	id := MakeID()
-	s.Entities[id] = e
+	s.insert(id, e)
+	for _, f := range s.OnAdd {
+		f(id, e)
+	}
 	return id
 }
 
-// EntitiesAt returns all entities at the given position sorted by ID
-// in increasing order.
-func (s *State) EntitiesAt(p XY) []Entity {
-	ids := []ID{}
+// insert puts the entity into the world under the given ID without
+// calling the hooks.
+func (s *State) insert(id ID, e Entity) {
+	s.Entities[id] = e
+	s.ids[e] = id
+	s.index(id, e.Pos())
+	s.energy[id] = 0
+}
+
+// Remove removes the entity with the given ID from the world. It is
+// safe to call during Update.
+func (s *State) Remove(id ID) {
+	e, ok := s.Entities[id]
+	if !ok {
+		return
+	}
+	for _, f := range s.OnRemove {
+		f(id, e)
+	}
+	s.unindex(id)
+	delete(s.Entities, id)
+	delete(s.ids, e)
+	delete(s.energy, id)
+	delete(s.effects, id)
+}
+
+// ID returns the ID of the entity if it is in the world.
+func (s *State) ID(e Entity) (ID, bool) {
+	id, ok := s.ids[e]
+	return id, ok
+}
+
+// Move moves the entity to p unless both the entity and another one
+// at p block movement. Reports whether the entity moved.
+func (s *State) Move(e Entity, p XY) bool {
+	id, ok := s.ids[e]
+	if !ok {
+		e.SetPos(p)
+		return true
+	}
+	if b, ok := s.BlockerAt(p); ok && b != e && blocks(e) {
+		return false
+	}
+	from := e.Pos()
+	s.unindex(id)
+	e.SetPos(p)
+	s.index(id, p)
+	for _, f := range s.OnMove {
+		f(id, e, from)
+	}
+	return true
+}
+
+// BlockerAt returns the entity at p which blocks movement.
+func (s *State) BlockerAt(p XY) (Entity, bool) {
 	for _, id := range s.at[p] {
-		ids = append(ids, id)
+		if e := s.Entities[id]; blocks(e) {
+			return e, true
+		}
 	}
+	return nil, false
+}
+
+// Blocked reports whether p is impassable or occupied by an entity
+// which blocks movement.
+func (s *State) Blocked(p XY) bool {
+	_, ok := s.BlockerAt(p)
+	return ok || !s.Tiles[p].Passable()
+}
+
+// index adds the entity with the given ID at p to the spatial index.
+func (s *State) index(id ID, p XY) {
+	s.pos[id] = p
+	s.at[p] = append(s.at[p], id)
+}
+
+// unindex removes the entity with the given ID from the spatial index.
+func (s *State) unindex(id ID) {
+	p, ok := s.pos[id]
+	if !ok {
+		return
+	}
+	ids := s.at[p]
+	for i, x := range ids {
+		if x == id {
+			ids = append(ids[:i], ids[i+1:]...)
+			break
+		}
+	}
+	if len(ids) == 0 {
+		delete(s.at, p)
+	} else {
+		s.at[p] = ids
+	}
+	delete(s.pos, id)
+}
+
+// entities returns the entities with the given IDs sorted by ID in
+// increasing order.
+func (s *State) entities(ids []ID) []Entity {
 	sort.Slice(ids, func(i, j int) bool {
 		return ids[i] < ids[j]
 	})
@@ -60,13 +176,94 @@ func (s *State) EntitiesAt(p XY) []Entity {
 	return e
 }
 
+// sortedIDs returns the IDs of the entities in increasing order.
+func (s *State) sortedIDs() []ID {
+	ids := make([]ID, 0, len(s.Entities))
+	for id := range s.Entities {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i] < ids[j]
+	})
+	return ids
+}
+
+// EntitiesAt returns all entities at the given position sorted by ID
+// in increasing order.
+func (s *State) EntitiesAt(p XY) []Entity {
+	return s.entities(append([]ID{}, s.at[p]...))
+}
+
+// EntitiesIn returns all entities in r sorted by ID in increasing
+// order.
+func (s *State) EntitiesIn(r Rect) []Entity {
+	ids := []ID{}
+	if r.Dx()*r.Dy() < len(s.pos) {
+		r.Apply(func(p XY) {
+			ids = append(ids, s.at[p]...)
+		})
+	} else {
+		for id, p := range s.pos {
+			if p.In(r) {
+				ids = append(ids, id)
+			}
+		}
+	}
+	return s.entities(ids)
+}
+
+// EntitiesWithin returns all entities closer to p than the radius r
+// sorted by ID in increasing order.
+func (s *State) EntitiesWithin(p XY, r int) []Entity {
+	e := []Entity{}
+	for _, x := range s.EntitiesIn(Rect{p.X - r, p.Y - r, p.X + r + 1, p.Y + r + 1}) {
+		if d := x.Pos().Sub(p); d.X*d.X+d.Y*d.Y < r*r {
+			e = append(e, x)
+		}
+	}
+	return e
+}
+
+// VisibleEntities returns all entities in the Field of View sorted
+// by ID in increasing order.
+func (s *State) VisibleEntities(fov map[XY]bool) []Entity {
+	ids := []ID{}
+	for id, p := range s.pos {
+		if fov[p] {
+			ids = append(ids, id)
+		}
+	}
+	return s.entities(ids)
+}
+
+// Nearest returns the entity nearest to p for which match returns
+// true. Ties are broken by the lowest ID.
+func (s *State) Nearest(p XY, match func(Entity) bool) (Entity, bool) {
+	best, dist, ok := ID(0), 0, false
+	for id, q := range s.pos {
+		d := q.Sub(p)
+		dd := d.X*d.X + d.Y*d.Y
+		if ok && (dd > dist || dd == dist && id > best) {
+			continue
+		}
+		if match(s.Entities[id]) {
+			best, dist, ok = id, dd, true
+		}
+	}
+	if !ok {
+		return nil, false
+	}
+	return s.Entities[best], true
+}
+
 // RandomPosition returns a random unoccupied position.
 func (s *State) RandomPosition() XY {
 	empty := []XY{}
 	for xy, tile := range s.Tiles {
-		if tile == Floor {
+		if tile == Floor && len(s.at[xy]) == 0 {
 			empty = append(empty, xy)
 		}
 	}
-	return empty[rand.Intn(len(empty))]
+	sortPoints(empty)
+	return empty[RNG.Intn(len(empty))]
 }
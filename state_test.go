@@ -0,0 +1,109 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStateSpatialIndex(t *testing.T) {
+	s := NewState()
+	stone := Items["stone"]
+	a, b, c := NewItem(stone, XY{0, 0}, 1), NewItem(stone, XY{3, 0}, 1), NewItem(stone, XY{10, 10}, 1)
+	for _, e := range []Entity{a, b, c} {
+		s.Add(e)
+	}
+
+	if e := s.EntitiesAt(XY{3, 0}); len(e) != 1 || e[0] != b {
+		t.Errorf("EntitiesAt({3 0}) = %v, want [b]", e)
+	}
+	s.Move(b, XY{1, 1})
+	if e := s.EntitiesAt(XY{3, 0}); len(e) != 0 {
+		t.Errorf("EntitiesAt({3 0}) after move = %v, want []", e)
+	}
+	if e := s.EntitiesIn(Rect{0, 0, 2, 2}); len(e) != 2 || e[0] != a || e[1] != b {
+		t.Errorf("EntitiesIn = %v, want [a b]", e)
+	}
+	if e := s.EntitiesWithin(XY{9, 9}, 2); len(e) != 1 || e[0] != c {
+		t.Errorf("EntitiesWithin = %v, want [c]", e)
+	}
+	if e := s.VisibleEntities(map[XY]bool{{10, 10}: true, {0, 0}: true}); len(e) != 2 || e[0] != a || e[1] != c {
+		t.Errorf("VisibleEntities = %v, want [a c]", e)
+	}
+	if e, ok := s.Nearest(XY{2, 2}, func(e Entity) bool { return e != b }); !ok || e != a {
+		t.Errorf("Nearest = %v, %v, want a", e, ok)
+	}
+	if e, ok := s.Nearest(XY{2, 2}, func(Entity) bool { return false }); ok || e != nil {
+		t.Errorf("Nearest matched nothing, got %v, %v", e, ok)
+	}
+}
+
+func TestStateRemove(t *testing.T) {
+	s := NewState()
+	var added, removed, moved []ID
+	s.OnAdd = append(s.OnAdd, func(id ID, e Entity) { added = append(added, id) })
+	s.OnRemove = append(s.OnRemove, func(id ID, e Entity) { removed = append(removed, id) })
+	s.OnMove = append(s.OnMove, func(id ID, e Entity, from XY) { moved = append(moved, id) })
+
+	a, b := NewItem(Items["stone"], XY{0, 0}, 1), NewItem(Items["stone"], XY{0, 0}, 1)
+	ida, idb := s.Add(a), s.Add(b)
+	if id, ok := s.ID(b); !ok || id != idb {
+		t.Errorf("ID(b) = %v, %v, want %v", id, ok, idb)
+	}
+	s.Move(a, XY{1, 0})
+	s.Remove(idb)
+	s.Remove(idb)
+
+	if _, ok := s.ID(b); ok {
+		t.Errorf("removed entity has an ID")
+	}
+	if e := s.EntitiesAt(XY{0, 0}); len(e) != 0 {
+		t.Errorf("EntitiesAt({0 0}) = %v, want []", e)
+	}
+	if len(added) != 2 || len(removed) != 1 || removed[0] != idb || len(moved) != 1 || moved[0] != ida {
+		t.Errorf("hooks: added %v, removed %v, moved %v", added, removed, moved)
+	}
+}
+
+func TestMinerRemovedWhenDead(t *testing.T) {
+	s := NewState()
+	Rect{0, 0, 5, 5}.Apply(func(p XY) { s.Tiles[p] = Floor })
+	s.Add(&Miner{XY{2, 2}, Rect{0, 0, 5, 5}, 0, s, minerStats})
+	for i := 0; i < 2; i++ {
+		s.Step()
+	}
+	if len(s.Entities) != 0 {
+		t.Errorf("len(Entities) = %d, want 0", len(s.Entities))
+	}
+}
+
+func TestStateBlocking(t *testing.T) {
+	s, p := arena(3, XY{0, 0})
+	m := NewMonster(Kinds["goblin"], XY{1, 0}, s)
+	s.Add(m)
+	it := NewItem(Items["stone"], XY{0, 1}, 1)
+	s.Add(it)
+
+	if s.Move(p, XY{1, 0}) || p.XY != (XY{0, 0}) {
+		t.Errorf("player moved onto the goblin")
+	}
+	if !s.Move(p, XY{0, 1}) || !s.Blocked(XY{0, 1}) {
+		t.Errorf("player did not move onto the stone")
+	}
+	if !s.Move(it, XY{1, 0}) {
+		t.Errorf("stone did not move under the goblin")
+	}
+	if e := displayedEntity(time.Now(), s.EntitiesAt(XY{1, 0})); e != m {
+		t.Errorf("displayed %v, want the goblin", e)
+	}
+
+	// Occupy all but one point.
+	for _, q := range []XY{{2, 0}, {0, 1}, {0, 2}, {1, 1}, {2, 1}, {1, 2}} {
+		s.Add(NewItem(Items["stone"], q, 1))
+	}
+	s.Move(p, XY{0, 0})
+	for i := 0; i < 10; i++ {
+		if q := s.RandomPosition(); q != (XY{2, 2}) {
+			t.Fatalf("RandomPosition() = %v, want {2 2}", q)
+		}
+	}
+}
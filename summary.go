@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// SummaryScreen shows the summary of a finished game.
+type SummaryScreen struct{}
+
+func (SummaryScreen) Update(g *Game) bool {
+	return !justPressed(ebiten.KeyEscape, ebiten.KeyEnter)
+}
+
+func (SummaryScreen) Draw(g *Game) {
+	lines := []string{
+		"You died.",
+		"",
+		fmt.Sprintf("Killed by:      %s", g.Player.KilledBy),
+		fmt.Sprintf("Turns survived: %d", g.State.Turn),
+		fmt.Sprintf("Monsters slain: %d", g.Player.Kills),
+		fmt.Sprintf("Tiles explored: %d", len(g.Player.Explored)),
+		"",
+		"Press Enter to quit.",
+	}
+	for i, s := range lines {
+		c := colorInfo
+		if i == 0 {
+			c = colorDanger
+		}
+		g.Terminal.Text(XY{2, 2 + i}, s, c)
+	}
+}
@@ -55,6 +55,17 @@ func (t *Terminal) Print(p XY, c Cell, op *ebiten.DrawImageOptions) {
 	t.Buffer.DrawImage(t.getCellImage(c), op)
 }
 
+// Text prints s starting at p one character per cell on a black
+// background.
+func (t *Terminal) Text(p XY, s string, fg color.RGBA) {
+	for _, r := range s {
+		if r != ' ' {
+			t.Print(p, Cell{Fg: fg, Bg: colorBlack, Symbol: r}, nil)
+		}
+		p.X++
+	}
+}
+
 type Cell struct {
 	Fg, Bg color.RGBA
 	Symbol rune
@@ -1,9 +1,28 @@
 package main
 
-import "image/color"
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"io"
+	"unicode/utf8"
+)
+
+//go:embed tiles.json
+var tilesData []byte
+
+func init() {
+	if err := RegisterTiles(bytes.NewReader(tilesData)); err != nil {
+		panic(err)
+	}
+}
 
 type Tile int
 
+// Built-in tiles used by the generators. Their properties are
+// defined in the tile registry like any other tile.
 const (
 	Wall Tile = iota
 	Floor
@@ -11,33 +30,209 @@ const (
 	Arch
 )
 
-// Opaque returns true if the tile can pass light.
+// TileDef describes a tile type.
+type TileDef struct {
+	Name      string   `json:"name"`
+	Glyph     glyph    `json:"glyph"`
+	Fg        hexColor `json:"fg"`
+	Bg        hexColor `json:"bg"`
+	Opaque    bool     `json:"opaque"`
+	Passable  bool     `json:"passable"`
+	Cost      int      `json:"cost"`
+	Diggable  bool     `json:"diggable"`
+	Flammable bool     `json:"flammable"`
+	Liquid    bool     `json:"liquid"`
+	Light     *Light   `json:"light"`
+
+	// Open and Close name the tiles a door turns into when opened and
+	// closed, Broken the one it turns into when bashed open. A locked
+	// door opens only with a key.
+	Open   string `json:"open"`
+	Close  string `json:"close"`
+	Broken string `json:"broken"`
+	Locked bool   `json:"locked"`
+
+	// Hardness is the digging effort needed to dig through a
+	// diggable tile and Rubble names the tile left behind.
+	Hardness int    `json:"hardness"`
+	Rubble   string `json:"rubble"`
+
+	// Trap names the trap sprung by stepping on the tile. A hidden
+	// trap turns into the Reveal tile when noticed and a revealed one
+	// into the Disarm tile when disarmed.
+	Trap   string `json:"trap"`
+	Reveal string `json:"reveal"`
+	Disarm string `json:"disarm"`
+}
+
+// tileDefs is the tile registry indexed by Tile.
+var tileDefs = []TileDef{
+	Wall:  {Name: "wall"},
+	Floor: {Name: "floor"},
+	Door:  {Name: "door"},
+	Arch:  {Name: "arch"},
+}
+
+// RegisterTiles reads a JSON list of tile definitions from r and
+// registers them. A definition with the name of an already registered
+// tile replaces it.
+func RegisterTiles(r io.Reader) error {
+	var defs []TileDef
+	if err := json.NewDecoder(r).Decode(&defs); err != nil {
+		return fmt.Errorf("tiles: %w", err)
+	}
+	for _, def := range defs {
+		if def.Name == "" {
+			return fmt.Errorf("tiles: definition without a name")
+		}
+		if t, ok := TileByName(def.Name); ok {
+			tileDefs[t] = def
+		} else {
+			tileDefs = append(tileDefs, def)
+		}
+	}
+	for _, def := range tileDefs {
+		for _, name := range []string{def.Open, def.Close, def.Broken, def.Rubble, def.Reveal, def.Disarm} {
+			if _, ok := TileByName(name); name != "" && !ok {
+				return fmt.Errorf("tiles: %s: unknown tile %q", def.Name, name)
+			}
+		}
+		if _, ok := traps[def.Trap]; def.Trap != "" && !ok {
+			return fmt.Errorf("tiles: %s: unknown trap %q", def.Name, def.Trap)
+		}
+	}
+	return nil
+}
+
+// TileByName returns the tile registered with the given name.
+func TileByName(name string) (Tile, bool) {
+	for i, def := range tileDefs {
+		if def.Name == name {
+			return Tile(i), true
+		}
+	}
+	return 0, false
+}
+
+// Def returns the definition of the tile.
+func (t Tile) Def() TileDef {
+	return tileDefs[t]
+}
+
+// Opaque returns true if the tile cannot pass light.
 func (t Tile) Opaque() bool {
-	return tileData[t].opaque
+	return tileDefs[t].Opaque
 }
 
+// Passable returns true if the tile can be walked on.
 func (t Tile) Passable() bool {
-	return tileData[t].passable
+	return tileDefs[t].Passable
+}
+
+// Cost returns the cost of moving onto the tile.
+func (t Tile) Cost() int {
+	return tileDefs[t].Cost
+}
+
+// Closed reports whether the tile is a closed door.
+func (t Tile) Closed() bool {
+	return tileDefs[t].Open != ""
 }
 
-var tileData = [...]struct {
-	opaque   bool
-	passable bool
-}{
-	Wall:  {true, false},
-	Floor: {false, true},
-	Door:  {true, true},
-	Arch:  {false, true},
+// Locked reports whether the tile is a locked door.
+func (t Tile) Locked() bool {
+	return tileDefs[t].Locked
+}
+
+// Opened returns the tile the door turns into when opened.
+func (t Tile) Opened() (Tile, bool) {
+	return TileByName(tileDefs[t].Open)
+}
+
+// Shut returns the tile the open door turns into when closed.
+func (t Tile) Shut() (Tile, bool) {
+	return TileByName(tileDefs[t].Close)
+}
+
+// Bashed returns the tile the door turns into when bashed open.
+func (t Tile) Bashed() (Tile, bool) {
+	return TileByName(tileDefs[t].Broken)
+}
+
+// Diggable reports whether the tile can be dug through.
+func (t Tile) Diggable() bool {
+	return tileDefs[t].Diggable
+}
+
+// Hardness returns the digging effort needed to dig through the tile.
+func (t Tile) Hardness() int {
+	return tileDefs[t].Hardness
+}
+
+// Rubble returns the tile left after digging through the tile.
+func (t Tile) Rubble() (Tile, bool) {
+	return TileByName(tileDefs[t].Rubble)
+}
+
+// Trap returns the name of the trap on the tile or "" if there is
+// none.
+func (t Tile) Trap() string {
+	return tileDefs[t].Trap
+}
+
+// Hidden reports whether the tile is a hidden trap.
+func (t Tile) Hidden() bool {
+	return tileDefs[t].Reveal != ""
+}
+
+// Revealed returns the tile the hidden trap turns into when noticed.
+func (t Tile) Revealed() (Tile, bool) {
+	return TileByName(tileDefs[t].Reveal)
+}
+
+// Disarmed returns the tile the trap turns into when disarmed.
+func (t Tile) Disarmed() (Tile, bool) {
+	return TileByName(tileDefs[t].Disarm)
+}
+
+// Background returns the background color of the tile.
+func (t Tile) Background() color.RGBA {
+	return color.RGBA(tileDefs[t].Bg)
 }
 
 // Symbol implements the Symboler interface.
 func (t Tile) Symbol() Symbol {
-	return tileSymbol[t]
+	def := tileDefs[t]
+	return Symbol{color.RGBA(def.Fg), rune(def.Glyph)}
 }
 
-var tileSymbol = [...]Symbol{
-	Wall:  {color.RGBA{0x45, 0x23, 0x0d, 0xff}, '▒'},
-	Floor: {color.RGBA{0x2a, 0x1d, 0x0d, 0xff}, '.'},
-	Door:  {color.RGBA{0xa5, 0x62, 0x43, 0xff}, 'Ṩ'},
-	Arch:  {color.RGBA{0x45, 0x23, 0x0d, 0xff}, 'ṧ'},
+// glyph is a rune encoded in JSON as a single character string.
+type glyph rune
+
+func (g *glyph) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	r, n := utf8.DecodeRuneInString(s)
+	if n == 0 || n != len(s) {
+		return fmt.Errorf("invalid glyph %q", s)
+	}
+	*g = glyph(r)
+	return nil
+}
+
+// hexColor is an opaque color encoded in JSON as "#rrggbb".
+type hexColor color.RGBA
+
+func (c *hexColor) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	*c = hexColor{A: 0xff}
+	if _, err := fmt.Sscanf(s, "#%02x%02x%02x", &c.R, &c.G, &c.B); err != nil {
+		return fmt.Errorf("invalid color %q", s)
+	}
+	return nil
 }
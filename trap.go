@@ -0,0 +1,195 @@
+package main
+
+// traps are the effects of the traps by name on the player who set
+// them off at p. Falling into a pit is handled by Game.Act. Monsters
+// know their traps and never set them off.
+var traps = map[string]func(s *State, pl *Player, p XY){
+	"pit": func(s *State, pl *Player, p XY) {},
+	"dart": func(s *State, pl *Player, p XY) {
+		s.AddEffect(pl, Effect{Poisoned, 6, 1})
+	},
+	"alarm": func(s *State, pl *Player, p XY) {
+		for _, e := range s.EntitiesWithin(p, alarmRadius) {
+			if m, ok := e.(*Monster); ok {
+				m.Mind.LastSeen = p
+				m.Mind.Remembered = true
+			}
+		}
+	},
+	"teleport": func(s *State, pl *Player, p XY) {
+		s.Move(pl, s.RandomPosition())
+	},
+}
+
+// remoteTraps are the traps which act away from the spot they are set
+// off at. Only these go off when disarming them fails.
+var remoteTraps = map[string]bool{"alarm": true}
+
+// alarmRadius is the distance an alarm trap is heard from.
+const alarmRadius = 20
+
+// PlaceTraps hides n random traps in the corridors and the room
+// entrances of the map.
+func PlaceTraps(tiles map[XY]Tile, rooms []Rect, n int) {
+	hidden := []Tile{}
+	for i := range tileDefs {
+		if t := Tile(i); t.Hidden() {
+			hidden = append(hidden, t)
+		}
+	}
+	if len(hidden) == 0 {
+		return
+	}
+	sites := trapSites(tiles, rooms)
+	for i := 0; i < n && len(sites) > 0; i++ {
+		j := RNG.Intn(len(sites))
+		tiles[sites[j]] = hidden[RNG.Intn(len(hidden))]
+		sites = append(sites[:j], sites[j+1:]...)
+	}
+}
+
+// trapSites returns the floor points outside of the rooms which are
+// either next to a door or an arch or lie in a passage one tile wide.
+func trapSites(tiles map[XY]Tile, rooms []Rect) []XY {
+	open := func(p XY) bool { return tiles[p].Passable() || tiles[p].Closed() }
+	sites := []XY{}
+	for _, p := range sortedPoints(tiles) {
+		if tiles[p] != Floor || inRooms(p, rooms) {
+			continue
+		}
+		entrance := false
+		for _, q := range p.Orthogonal() {
+			if t := tiles[q]; t == Arch || t.Closed() || t.Def().Close != "" {
+				entrance = true
+			}
+		}
+		n, s, w, e := open(p.Add(North)), open(p.Add(South)), open(p.Add(West)), open(p.Add(East))
+		corridor := n && s && !w && !e || w && e && !n && !s
+		if entrance || corridor {
+			sites = append(sites, p)
+		}
+	}
+	return sites
+}
+
+// inRooms reports whether p is in one of the rooms.
+func inRooms(p XY, rooms []Rect) bool {
+	for _, r := range rooms {
+		if r.Contains(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// SpringTrap sets off the trap at p on the player, revealing it.
+func (s *State) SpringTrap(pl *Player, p XY) {
+	t := s.Tiles[p]
+	name := t.Trap()
+	if name == "" {
+		return
+	}
+	if r, ok := t.Revealed(); ok {
+		s.Tiles[p], t = r, r
+	}
+	s.Events.Publish(Sprung{pl, p, t})
+	traps[name](s, pl, p)
+}
+
+// RevealTrap reveals the hidden trap at p to the entity.
+func (s *State) RevealTrap(e Entity, p XY) {
+	if r, ok := s.Tiles[p].Revealed(); ok {
+		s.Tiles[p] = r
+		s.Events.Publish(Spotted{e, p, r})
+	}
+}
+
+// Disarm lets the player try to disarm the revealed trap at p. The
+// chance grows with the perception of the player; a failure may set
+// off a remote trap. Reports whether the trap was disarmed.
+func (s *State) Disarm(pl *Player, p XY) bool {
+	t := s.Tiles[p]
+	safe, ok := t.Disarmed()
+	if !ok {
+		return false
+	}
+	chance := 0.4 + 0.05*float64(pl.Effective[PerceptionStat])
+	ok = RNG.Float64() < chance
+	s.Events.Publish(Disarmed{pl, p, t, ok})
+	if ok {
+		s.Tiles[p] = safe
+	} else if remoteTraps[t.Trap()] && RNG.Float64() < 0.5 {
+		s.SpringTrap(pl, p)
+	}
+	return ok
+}
+
+// spot reveals the hidden traps in the Field of View of the player.
+// The chance grows with the perception and the factor and falls with
+// the squared distance.
+func (p *Player) spot(factor float64) {
+	hidden := []XY{}
+	for q := range p.FOV {
+		if p.State.Tiles[q].Hidden() {
+			hidden = append(hidden, q)
+		}
+	}
+	sortPoints(hidden)
+	for _, q := range hidden {
+		d := distance(p.XY, q)
+		if d < 1 {
+			d = 1
+		}
+		chance := factor * float64(p.Effective[PerceptionStat]) / float64(20*d*d)
+		if RNG.Float64() < chance {
+			p.State.RevealTrap(p, q)
+		}
+	}
+}
+
+// disarm tries to disarm the revealed trap in the direction or the
+// first adjacent one if the direction is zero. Reports false if there
+// is no trap to disarm.
+func (p *Player) disarm(dir XY) bool {
+	for _, q := range p.adjacent(dir) {
+		t := p.State.Tiles[q]
+		if t.Trap() == "" || t.Hidden() {
+			continue
+		}
+		if _, ok := t.Disarmed(); !ok {
+			p.State.Events.Publish(Notice{q, sentence("The %s cannot be disarmed.", t.Def().Name)})
+			return false
+		}
+		p.State.Disarm(p, q)
+		return true
+	}
+	return false
+}
+
+// Sprung is published when a player sets off a trap.
+type Sprung struct {
+	Entity Entity
+	XY     XY
+	Tile   Tile
+}
+
+func (e Sprung) At() XY { return e.XY }
+
+// Spotted is published when an entity notices a hidden trap.
+type Spotted struct {
+	Entity Entity
+	XY     XY
+	Tile   Tile
+}
+
+func (e Spotted) At() XY { return e.XY }
+
+// Disarmed is published when a player tries to disarm a trap.
+type Disarmed struct {
+	Entity Entity
+	XY     XY
+	Tile   Tile
+	OK     bool
+}
+
+func (e Disarmed) At() XY { return e.XY }
@@ -0,0 +1,129 @@
+package main
+
+import "testing"
+
+// trapped returns an arena with the named trap tile at {2 1} and a
+// player at {1 1}.
+func trapped(t *testing.T, name string) (*State, *Player) {
+	t.Helper()
+	s, p := arena(5, XY{1, 1})
+	trap, ok := TileByName(name)
+	if !ok {
+		t.Fatalf("no tile %q", name)
+	}
+	s.Tiles[XY{2, 1}] = trap
+	return s, p
+}
+
+func TestTrapDart(t *testing.T) {
+	s, p := trapped(t, "hidden dart trap")
+	dart, _ := TileByName("dart trap")
+	p.Do(Command{Action: MoveAction, Dir: East})
+	if p.XY != (XY{2, 1}) || s.Tiles[p.XY] != dart {
+		t.Errorf("player at %v on %v, want {2 1} on a revealed dart trap", p.XY, s.Tiles[p.XY])
+	}
+	if !s.HasEffect(p, Poisoned) {
+		t.Errorf("dart did not poison the player")
+	}
+}
+
+func TestTrapAlarm(t *testing.T) {
+	s, p := trapped(t, "alarm trap")
+	m := NewMonster(Kinds["goblin"], XY{4, 4}, s)
+	s.Add(m)
+	p.Do(Command{Action: MoveAction, Dir: East})
+	if !m.Mind.Remembered || m.Mind.LastSeen != (XY{2, 1}) {
+		t.Errorf("goblin mind = %+v, want to remember {2 1}", m.Mind)
+	}
+}
+
+func TestTrapTeleport(t *testing.T) {
+	s, p := trapped(t, "teleport trap")
+	// Leave {4 4} the only free floor.
+	for _, q := range sortedPoints(s.Tiles) {
+		if s.Tiles[q] == Floor && q != (XY{4, 4}) {
+			s.Drop(NewItem(Items["stone"], q, 1), q)
+		}
+	}
+	p.Do(Command{Action: MoveAction, Dir: East})
+	if p.XY != (XY{4, 4}) {
+		t.Errorf("player at %v, want teleported to {4 4}", p.XY)
+	}
+}
+
+func TestTrapSpotAndDisarm(t *testing.T) {
+	s, p := trapped(t, "hidden dart trap")
+	p.Base[PerceptionStat] = 100
+	p.Recompute()
+	s.UpdateLight()
+	p.UpdateFOV()
+	if p.Do(Command{Action: DisarmAction}) {
+		t.Errorf("disarmed a hidden trap")
+	}
+	p.Do(Command{Action: SearchAction})
+	dart, _ := TileByName("dart trap")
+	if s.Tiles[XY{2, 1}] != dart {
+		t.Fatalf("search did not reveal the trap")
+	}
+	if !p.Do(Command{Action: DisarmAction}) || s.Tiles[XY{2, 1}] != Floor {
+		t.Errorf("tile %v, want the trap disarmed", s.Tiles[XY{2, 1}])
+	}
+}
+
+func TestTrapPit(t *testing.T) {
+	g := NewGame(1)
+	pit, _ := TileByName("pit")
+	var q XY
+	for _, q = range g.Player.XY.Orthogonal() {
+		if g.State.Tiles[q].Passable() && !g.State.Blocked(q) {
+			break
+		}
+	}
+	g.State.Tiles[q] = pit
+	g.Act(Command{Action: MoveAction, Dir: q.Sub(g.Player.XY)})
+	if g.Depth != 2 || g.Player.State != g.State || g.State.Tiles[g.Player.XY] != Floor {
+		t.Errorf("depth %d, player on %v, want depth 2", g.Depth, g.State.Tiles[g.Player.XY])
+	}
+	if len(g.Player.Inventory.Items) == 0 {
+		t.Errorf("the player lost the inventory")
+	}
+}
+
+func TestPlaceTraps(t *testing.T) {
+	tiles := map[XY]Tile{}
+	// A room with a door leading into a corridor.
+	Rect{0, 0, 3, 3}.Apply(func(p XY) { tiles[p] = Floor })
+	tiles[XY{3, 1}] = Door
+	Rect{4, 1, 9, 2}.Apply(func(p XY) { tiles[p] = Floor })
+	PlaceTraps(tiles, []Rect{{0, 0, 3, 3}}, 100)
+	for p, tile := range tiles {
+		inRoom := p.In(Rect{0, 0, 3, 3})
+		if tile.Trap() != "" && (inRoom || !tile.Hidden()) {
+			t.Errorf("trap %v at %v", tile.Def().Name, p)
+		}
+		if !inRoom && p.X > 3 && p.X < 8 && tile.Trap() == "" {
+			t.Errorf("no trap in the corridor at %v", p)
+		}
+	}
+}
+
+func TestDisarmFailure(t *testing.T) {
+	for _, name := range []string{"dart trap", "teleport trap", "alarm trap"} {
+		s, p := trapped(t, name)
+		m := NewMonster(Kinds["goblin"], XY{4, 4}, s)
+		s.Add(m)
+		p.Base[PerceptionStat] = -100
+		p.Recompute()
+		for i := 0; i < 20; i++ {
+			if s.Disarm(p, XY{2, 1}) {
+				t.Fatalf("disarmed the %s", name)
+			}
+		}
+		if p.XY != (XY{1, 1}) || s.HasEffect(p, Poisoned) {
+			t.Errorf("the %s acted on the player at %v", name, p.XY)
+		}
+		if alarm := name == "alarm trap"; m.Mind.Remembered != alarm {
+			t.Errorf("the %s alarmed the goblin: %v", name, m.Mind.Remembered)
+		}
+	}
+}
@@ -1,5 +1,7 @@
 package main
 
+import "sort"
+
 // XY represents a position on the grid.
 type XY struct {
 	X int
@@ -11,6 +13,11 @@ func (p XY) Pos() XY {
 	return p
 }
 
+// SetPos implements the Poser interface.
+func (p *XY) SetPos(q XY) {
+	*p = q
+}
+
 // Add adds all terms to p.
 func (p XY) Add(terms ...XY) XY {
 	for _, t := range terms {
@@ -72,25 +79,6 @@ func (p XY) NE() XY { return p.Add(North, East) }
 func (p XY) SW() XY { return p.Add(South, West) }
 func (p XY) SE() XY { return p.Add(South, East) }
 
-// FOV calculates the Field of View of p given the radius r.
-// opaque returns true if its argument cannot pass light.
-func (p XY) FOV(r int, opaque func(XY) bool) map[XY]bool {
-	points := map[XY]bool{}
-	for i := -r; i <= r; i++ {
-		for j := -r; j <= r; j++ {
-			if i*i+j*j < r*r {
-				for _, q := range p.Line(p.Add(XY{i, j})) {
-					points[q] = true
-					if opaque(q) {
-						break
-					}
-				}
-			}
-		}
-	}
-	return points
-}
-
 // Line returns all points of the line from p to q.
 // Implemented using Bresenham's Line Algorithm.
 func (p XY) Line(q XY) []XY {
@@ -138,3 +126,23 @@ func (p XY) Line(q XY) []XY {
 	}
 	return points
 }
+
+// sortedPoints returns the keys of the map in row order.
+func sortedPoints[T any](m map[XY]T) []XY {
+	ps := make([]XY, 0, len(m))
+	for p := range m {
+		ps = append(ps, p)
+	}
+	sortPoints(ps)
+	return ps
+}
+
+// sortPoints sorts the points in row order.
+func sortPoints(ps []XY) {
+	sort.Slice(ps, func(i, j int) bool {
+		if ps[i].Y != ps[j].Y {
+			return ps[i].Y < ps[j].Y
+		}
+		return ps[i].X < ps[j].X
+	})
+}